@@ -0,0 +1,138 @@
+package mux_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+func benchmarkPatterns(n int) []string {
+	patterns := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			patterns = append(patterns, fmt.Sprintf(`/api/resource%d`, i))
+		case 1:
+			patterns = append(patterns, fmt.Sprintf(`/api/resource%d/{id}`, i))
+		default:
+			patterns = append(patterns, fmt.Sprintf(`/api/resource%d/{id:^[0-9]+}/items/{item}`, i))
+		}
+	}
+	return patterns
+}
+
+func BenchmarkRouter(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		patterns := benchmarkPatterns(n)
+		// the last route with the most variables is the worst case
+		// for a linear scan
+		last := n - 1
+		for last%3 != 2 {
+			last--
+		}
+		path := fmt.Sprintf(`/api/resource%d/12345/items/abc`, last)
+
+		b.Run(fmt.Sprintf("tree/%d", n), func(b *testing.B) {
+			var r mux.Router
+			for _, pattern := range patterns {
+				if err := r.Get(pattern, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				b.Fatalf(`expected %q to match, got status %d`, path, w.Code)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(w, req)
+			}
+		})
+
+		// linear serves the same request through linearRouter, which
+		// dispatches the way the Router did before routes were compiled
+		// into a tree
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			var r linearRouter
+			for _, pattern := range patterns {
+				if err := r.add(http.MethodGet, pattern, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				b.Fatalf(`expected %q to match, got status %d`, path, w.Code)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+type linearValuesKey struct{}
+
+type linearRoute struct {
+	method  string
+	matcher *pathmatch.Matcher
+	handler http.Handler
+}
+
+// linearRouter is a copy of the Router from before routes were compiled
+// into a tree: each route is tried in registration order until one of
+// them matches. It does not support any of the features that were added
+// since, such as middlewares, CORS or 405 responses, so the comparison
+// with the Router favors linearRouter
+type linearRouter struct {
+	mu     sync.RWMutex
+	routes []*linearRoute
+}
+
+func (r *linearRouter) add(method, pattern string, hh http.Handler) error {
+	m, err := pathmatch.Parse(pattern)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, &linearRoute{method: method, matcher: m, handler: hh})
+	return nil
+}
+
+func (r *linearRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, route := range r.routes {
+		if method := route.method; method != "" {
+			if req.Method != method {
+				continue
+			}
+		}
+
+		mv, err := route.matcher.Match(req.URL.Path)
+		if err != nil {
+			continue
+		}
+
+		ctx := context.WithValue(req.Context(), linearValuesKey{}, mv)
+		route.handler.ServeHTTP(w, req.WithContext(ctx))
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
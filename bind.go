@@ -0,0 +1,201 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ErrRequired is wrapped in a *BindError when a required value is missing.
+var ErrRequired = errors.New(`required value is missing`)
+
+// BindError is the error returned by `mux.Bind` when a value is missing
+// or cannot be converted to the type of the struct field.
+type BindError struct {
+	// Source is where the value was looked up: `path`, `query`, or `header`
+	Source string
+
+	// Name is the name of the path variable, query parameter, or header
+	Name string
+
+	// Field is the name of the struct field
+	Field string
+
+	// Value is the raw value that failed to be converted
+	Value string
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *BindError) Error() string {
+	if errors.Is(e.Err, ErrRequired) {
+		return fmt.Sprintf(`%s %q: %s`, e.Source, e.Name, e.Err)
+	}
+	return fmt.Sprintf(`%s %q: invalid value %q: %s`, e.Source, e.Name, e.Value, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+var bindSources = []string{`path`, `query`, `header`}
+
+// Bind populates the fields of the struct pointed to by `dst` with
+// values from the request. Fields are bound using struct tags that
+// specify where the value is looked up:
+//
+//	type Params struct {
+//		ID      int      `path:"id,required"`
+//		Page    int      `query:"page" default:"1"`
+//		Tags    []string `query:"tag"`
+//		TraceID string   `header:"X-Trace-Id"`
+//	}
+//
+// `path` refers to the variables available through `mux.Vars`, `query`
+// refers to query parameters, and `header` refers to request headers.
+// If the value is missing, the value of the `default` tag is used
+// instead. If there is no default value and the `required` option is
+// specified, a *BindError wrapping ErrRequired is returned.
+//
+// Values are converted using the same rules as `mux.Var`. Fields may
+// also be pointers, which are left nil when the value is missing, or
+// slices, which receive all values of a query parameter or header.
+//
+// Conversion errors are reported as a *BindError, naming the offending
+// value. Binding stops at the first error.
+func Bind(req *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf(`mux.Bind: destination must be a non-nil pointer to a struct, got %T`, dst)
+	}
+
+	return bindStruct(req, Vars(req), rv.Elem())
+}
+
+func bindStruct(req *http.Request, vars Values, rv reflect.Value) error {
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(req, vars, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, source := range bindSources {
+			tag, ok := field.Tag.Lookup(source)
+			if !ok {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(tag, `,`)
+			required := opts == `required`
+			if name == "" {
+				name = field.Name
+			}
+
+			values := lookupBindValues(req, vars, source, name)
+			if len(values) == 0 {
+				if def, ok := field.Tag.Lookup(`default`); ok {
+					values = []string{def}
+				} else if required {
+					return &BindError{Source: source, Name: name, Field: field.Name, Err: ErrRequired}
+				}
+			}
+			if len(values) == 0 {
+				break
+			}
+
+			if err := bindField(rv.Field(i), values); err != nil {
+				var value string
+				if len(values) == 1 {
+					value = values[0]
+				} else {
+					value = strings.Join(values, `,`)
+				}
+				return &BindError{Source: source, Name: name, Field: field.Name, Value: value, Err: err}
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func lookupBindValues(req *http.Request, vars Values, source, name string) []string {
+	switch source {
+	case `path`:
+		if vars.Has(name) {
+			return []string{vars.Get(name)}
+		}
+	case `query`:
+		return req.URL.Query()[name]
+	case `header`:
+		return req.Header.Values(name)
+	}
+	return nil
+}
+
+func bindField(fv reflect.Value, values []string) error {
+	typ := fv.Type()
+
+	// slices receive all values, unless a decoder has been registered
+	// for the slice type itself
+	if typ.Kind() == reflect.Slice && !hasDecoder(typ) {
+		slice := reflect.MakeSlice(typ, 0, len(values))
+		for _, s := range values {
+			v, err := decodeValue(typ.Elem(), s)
+			if err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, v)
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	v, err := decodeValue(typ, values[0])
+	if err != nil {
+		return err
+	}
+	fv.Set(v)
+	return nil
+}
+
+// decodeValue is the same as decode, except that pointer types are
+// allocated and the value is decoded into the element type
+func decodeValue(typ reflect.Type, s string) (reflect.Value, error) {
+	if typ.Kind() == reflect.Pointer && !hasDecoder(typ) {
+		v, err := decodeValue(typ.Elem(), s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+
+	v, err := decode(typ, s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	// values returned by decoders may be of a different, but
+	// convertible type (e.g. named types)
+	if v.Type() != typ {
+		v = v.Convert(typ)
+	}
+	return v, nil
+}
+
+func hasDecoder(typ reflect.Type) bool {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	_, ok := decoders[typ]
+	return ok
+}
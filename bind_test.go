@@ -0,0 +1,94 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type Pagination struct {
+	Page    int `query:"page" default:"1"`
+	PerPage int `query:"per_page" default:"20"`
+}
+
+type ListParams struct {
+	Pagination
+	Tenant  string   `path:"tenant,required"`
+	ID      uint64   `path:"id,required"`
+	Format  string   `path:"format" default:"json"`
+	Tags    []string `query:"tag"`
+	Limit   *int     `query:"limit"`
+	TraceID string   `header:"X-Trace-Id"`
+	Verbose bool     `query:"verbose,required"`
+	ignored string   `path:"tenant"`
+}
+
+func TestBind(t *testing.T) {
+	var params ListParams
+	var bindErr error
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/tenants/{tenant}/items/{id}`, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		params = ListParams{}
+		bindErr = mux.Bind(r, &params)
+	})), `r.Get should succeed`)
+
+	serve := func(target string, hdr http.Header) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range hdr {
+			req.Header[k] = v
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		serve(`/tenants/acme/items/123?page=3&tag=a&tag=b&verbose=true&limit=5`, http.Header{`X-Trace-Id`: {`abc`}})
+		require.NoError(t, bindErr, `mux.Bind should succeed`)
+
+		limit := 5
+		require.Equal(t, ListParams{
+			Pagination: Pagination{Page: 3, PerPage: 20},
+			Tenant:     `acme`,
+			ID:         123,
+			Format:     `json`,
+			Tags:       []string{`a`, `b`},
+			Limit:      &limit,
+			TraceID:    `abc`,
+			Verbose:    true,
+		}, params, `params should match`)
+	})
+	t.Run("missing optional pointer", func(t *testing.T) {
+		serve(`/tenants/acme/items/123?verbose=0`, nil)
+		require.NoError(t, bindErr, `mux.Bind should succeed`)
+		require.Nil(t, params.Limit, `missing pointer fields should remain nil`)
+		require.Equal(t, 1, params.Page, `default value should be used`)
+	})
+	t.Run("missing required", func(t *testing.T) {
+		serve(`/tenants/acme/items/123`, nil)
+
+		var berr *mux.BindError
+		require.True(t, errors.As(bindErr, &berr), `error should be a *mux.BindError`)
+		require.True(t, errors.Is(bindErr, mux.ErrRequired), `error should wrap mux.ErrRequired`)
+		require.Equal(t, `query`, berr.Source)
+		require.Equal(t, `verbose`, berr.Name)
+		require.Equal(t, `Verbose`, berr.Field)
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		serve(`/tenants/acme/items/abc?verbose=1`, nil)
+
+		var berr *mux.BindError
+		require.True(t, errors.As(bindErr, &berr), `error should be a *mux.BindError`)
+		require.Equal(t, `path`, berr.Source)
+		require.Equal(t, `id`, berr.Name)
+		require.Equal(t, `abc`, berr.Value)
+	})
+	t.Run("invalid destination", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, `/`, nil)
+		require.Error(t, mux.Bind(req, params), `non-pointer destinations should be rejected`)
+		require.Error(t, mux.Bind(req, (*ListParams)(nil)), `nil destinations should be rejected`)
+	})
+}
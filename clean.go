@@ -0,0 +1,123 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+	gopath "path"
+	"strings"
+)
+
+// CleanPathMode determines how the Router handles requests whose paths
+// are not canonical: paths with duplicate slashes or `.` and `..`
+// segments, and paths that do not match any route as they are, but would
+// match with the trailing slash added or removed.
+type CleanPathMode int
+
+const (
+	// CleanPathStrict matches paths exactly as they are sent by the
+	// client. This is the default
+	CleanPathStrict CleanPathMode = iota
+
+	// CleanPathRedirect redirects the client to the canonical path. GET
+	// and HEAD requests are redirected with 301 Moved Permanently, while
+	// other requests are redirected with 308 Permanent Redirect, so that
+	// the method and body are preserved. The query string is preserved
+	// in both cases
+	CleanPathRedirect
+
+	// CleanPathTransparent dispatches the request as if the canonical
+	// path had been requested. The path of the request seen by the
+	// handler is the canonical path
+	CleanPathTransparent
+)
+
+// canonicalPath returns the path that the request should be dispatched
+// to, if the path of the request is not canonical.
+//
+// Paths are cleaned up by removing duplicate slashes, and by resolving
+// `.` and `..` segments, regardless of whether they match a route as they
+// are, so that such segments never reach the handlers. If the cleaned up
+// path does not match any route, the trailing slash is added or removed.
+func (r *Router) canonicalPath(t *table, req *http.Request) (string, bool) {
+	mr := newMatchRequest(req)
+	matches := func(s string) bool {
+		// the method is not taken into account, so that requests with
+		// the wrong method are answered with 405 at the canonical path
+		return t.tree.Lookup(s, func(key int) bool {
+			return t.ranked[t.lookup(key)].accepts(mr)
+		}) >= 0
+	}
+
+	p := r.matchPath(req)
+	cleaned := cleanPath(p)
+	if matches(cleaned) {
+		return cleaned, cleaned != p
+	}
+	if toggled, ok := toggleTrailingSlash(cleaned); ok && matches(toggled) {
+		return toggled, true
+	}
+	return cleaned, cleaned != p
+}
+
+// cleanPath removes duplicate slashes and resolves `.` and `..` segments,
+// while keeping the trailing slash, if any
+func cleanPath(p string) string {
+	if p == "" {
+		return `/`
+	}
+	if p[0] != '/' {
+		p = `/` + p
+	}
+
+	cleaned := gopath.Clean(p)
+	if cleaned != `/` && (strings.HasSuffix(p, `/`) || strings.HasSuffix(p, `/.`) || strings.HasSuffix(p, `/..`)) {
+		cleaned += `/`
+	}
+	return cleaned
+}
+
+func toggleTrailingSlash(p string) (string, bool) {
+	if p == `/` {
+		return "", false
+	}
+	if strings.HasSuffix(p, `/`) {
+		return strings.TrimSuffix(p, `/`), true
+	}
+	return p + `/`, true
+}
+
+// cleanRequest handles requests whose paths are not canonical, according
+// to `Router.CleanPath`. It returns true if the request has been answered
+// with a redirect. Otherwise, it returns the request to dispatch, whose
+// path may have been replaced with the canonical path
+func (r *Router) cleanRequest(t *table, w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	if r.CleanPath == CleanPathStrict || len(t.ranked) == 0 {
+		return req, false
+	}
+
+	p, ok := r.canonicalPath(t, req)
+	if !ok {
+		return req, false
+	}
+
+	if r.CleanPath == CleanPathRedirect {
+		location := mountPrefix(req) + r.escapeMatchPath(p)
+		if req.URL.RawQuery != "" {
+			location += `?` + req.URL.RawQuery
+		}
+		code := http.StatusPermanentRedirect
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		w.Header().Set(`Location`, location)
+		w.WriteHeader(code)
+		return req, true
+	}
+
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	r.setMatchPath(r2.URL, p)
+	return r2, false
+}
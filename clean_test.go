@@ -0,0 +1,159 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanPath(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `%s %s id=%s`, r.Method, r.URL.Path, mux.Vars(r).Get(`id`))
+	})
+
+	newRouter := func(t *testing.T, mode mux.CleanPathMode) *mux.Router {
+		t.Helper()
+		r := &mux.Router{CleanPath: mode}
+		require.NoError(t, r.Get(`/foo/bar/{id}`, echo), `r.Get should succeed`)
+		require.NoError(t, r.Post(`/foo/bar/{id}`, echo), `r.Post should succeed`)
+		require.NoError(t, r.Get(`/dir/`, echo), `r.Get should succeed`)
+		return r
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		r := newRouter(t, mux.CleanPathStrict)
+		for _, path := range []string{`/foo/bar/123/`, `/foo//bar/123`, `/foo/./bar/123`, `/dir`} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusNotFound, w.Code, `%s should not match`, path)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		r := newRouter(t, mux.CleanPathRedirect)
+		testcases := []struct {
+			Method   string
+			Path     string
+			Status   int
+			Location string
+		}{
+			{Method: http.MethodGet, Path: `/foo/bar/123`, Status: http.StatusOK},
+			{Method: http.MethodGet, Path: `/foo/bar/123/`, Status: http.StatusMovedPermanently, Location: `/foo/bar/123`},
+			{Method: http.MethodGet, Path: `/foo//bar/123`, Status: http.StatusMovedPermanently, Location: `/foo/bar/123`},
+			{Method: http.MethodGet, Path: `/foo/./bar/baz/../123`, Status: http.StatusMovedPermanently, Location: `/foo/bar/123`},
+			{Method: http.MethodGet, Path: `/foo//bar/123/?q=1&r=2`, Status: http.StatusMovedPermanently, Location: `/foo/bar/123?q=1&r=2`},
+			{Method: http.MethodHead, Path: `/dir`, Status: http.StatusMovedPermanently, Location: `/dir/`},
+			{Method: http.MethodPost, Path: `/foo/bar/123/`, Status: http.StatusPermanentRedirect, Location: `/foo/bar/123`},
+			{Method: http.MethodDelete, Path: `/foo/bar/123/`, Status: http.StatusPermanentRedirect, Location: `/foo/bar/123`},
+			{Method: http.MethodGet, Path: `/foo/bar`, Status: http.StatusNotFound},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Method+" "+tc.Path, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+				require.Equal(t, tc.Status, w.Code, `status code should match`)
+				require.Equal(t, tc.Location, w.Header().Get(`Location`), `location should match`)
+			})
+		}
+	})
+
+	t.Run("transparent", func(t *testing.T) {
+		r := newRouter(t, mux.CleanPathTransparent)
+		testcases := []struct {
+			Method   string
+			Path     string
+			Status   int
+			Expected string
+		}{
+			{Method: http.MethodGet, Path: `/foo/bar/123/`, Status: http.StatusOK, Expected: `GET /foo/bar/123 id=123`},
+			{Method: http.MethodGet, Path: `/foo//bar/./123`, Status: http.StatusOK, Expected: `GET /foo/bar/123 id=123`},
+			{Method: http.MethodPost, Path: `/foo/x/../bar/123`, Status: http.StatusOK, Expected: `POST /foo/bar/123 id=123`},
+			{Method: http.MethodGet, Path: `/dir`, Status: http.StatusOK, Expected: `GET /dir/ id=`},
+			{Method: http.MethodPut, Path: `/foo/bar/123/`, Status: http.StatusMethodNotAllowed},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Method+" "+tc.Path, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+				require.Equal(t, tc.Status, w.Code, `status code should match`)
+				if tc.Expected != "" {
+					require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+				}
+			})
+		}
+	})
+
+	t.Run("escaped path", func(t *testing.T) {
+		r := newRouter(t, mux.CleanPathRedirect)
+		r.MatchEscapedPath = true
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/foo//bar/a%2Fb/`, nil))
+		require.Equal(t, http.StatusMovedPermanently, w.Code, `status code should match`)
+		require.Equal(t, `/foo/bar/a%2Fb`, w.Header().Get(`Location`), `location should keep encoded slashes`)
+	})
+
+	t.Run("dot segments and duplicate slashes", func(t *testing.T) {
+		// paths that match a route as they are must still be cleaned
+		// up, so that the variables never hold such segments
+		values := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `%s path=%s id=%s`, r.URL.Path, mux.Vars(r).Get(`path`), mux.Vars(r).Get(`id`))
+		})
+		newRouter := func(t *testing.T, mode mux.CleanPathMode) *mux.Router {
+			t.Helper()
+			r := &mux.Router{CleanPath: mode}
+			require.NoError(t, r.Get(`/static/{path...}`, values), `r.Get should succeed`)
+			require.NoError(t, r.Get(`/users/{id}/posts`, values), `r.Get should succeed`)
+			return r
+		}
+
+		testcases := []struct {
+			Path     string
+			Location string
+			Status   int
+			Expected string
+		}{
+			{Path: `/static/../../etc/passwd`, Location: `/etc/passwd`, Status: http.StatusNotFound},
+			{Path: `/static/css/../../static/app.js`, Location: `/static/app.js`, Status: http.StatusOK, Expected: `/static/app.js path=app.js id=`},
+			{Path: `/static//a`, Location: `/static/a`, Status: http.StatusOK, Expected: `/static/a path=a id=`},
+			{Path: `/static/./a`, Location: `/static/a`, Status: http.StatusOK, Expected: `/static/a path=a id=`},
+			{Path: `/users/./posts`, Location: `/users/posts`, Status: http.StatusNotFound},
+			{Path: `/users/../posts`, Location: `/posts`, Status: http.StatusNotFound},
+			{Path: `/users//posts`, Location: `/users/posts`, Status: http.StatusNotFound},
+			{Path: `/users/1/./posts`, Location: `/users/1/posts`, Status: http.StatusOK, Expected: `/users/1/posts path= id=1`},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Path, func(t *testing.T) {
+				r := newRouter(t, mux.CleanPathRedirect)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+				require.Equal(t, http.StatusMovedPermanently, w.Code, `status code should match`)
+				require.Equal(t, tc.Location, w.Header().Get(`Location`), `location should match`)
+
+				r = newRouter(t, mux.CleanPathTransparent)
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+				require.Equal(t, tc.Status, w.Code, `status code should match`)
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			})
+		}
+	})
+
+	t.Run("mounted", func(t *testing.T) {
+		inner := newRouter(t, mux.CleanPathRedirect)
+		var root mux.Router
+		require.NoError(t, root.Mount(`/tenants/{tenant}`, inner), `root.Mount should succeed`)
+
+		w := httptest.NewRecorder()
+		root.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/tenants/acme/foo//bar/123?q=1`, nil))
+		require.Equal(t, http.StatusMovedPermanently, w.Code, `status code should match`)
+		require.Equal(t, `/tenants/acme/foo/bar/123?q=1`, w.Header().Get(`Location`), `location should include the mount prefix`)
+	})
+}
@@ -0,0 +1,194 @@
+package mux
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+// ConflictPolicy determines what happens when a route that conflicts
+// with an existing route is registered.
+type ConflictPolicy int
+
+const (
+	// ConflictIgnore registers conflicting routes without any checks.
+	// This is the default
+	ConflictIgnore ConflictPolicy = iota
+
+	// ConflictWarn registers conflicting routes, and reports each
+	// conflict to `Router.OnConflict`
+	ConflictWarn
+
+	// ConflictError rejects conflicting routes. The error returned from
+	// the registration method is a *Conflict
+	ConflictError
+)
+
+// ConflictKind describes how two routes conflict.
+type ConflictKind int
+
+const (
+	// ConflictDuplicate means that both routes match exactly the same requests
+	ConflictDuplicate ConflictKind = iota + 1
+
+	// ConflictShadowed means that every request matched by the route is
+	// matched by the preferred route, and therefore the route is never used
+	ConflictShadowed
+
+	// ConflictAmbiguous means that some requests are matched by both
+	// routes, and are handled by the preferred route
+	ConflictAmbiguous
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictDuplicate:
+		return `duplicate`
+	case ConflictShadowed:
+		return `shadowed`
+	case ConflictAmbiguous:
+		return `ambiguous`
+	default:
+		return `unknown`
+	}
+}
+
+// Conflict describes a conflict between two routes.
+type Conflict struct {
+	Kind ConflictKind
+
+	// Route is the route that is shadowed by, or ambiguous with,
+	// the preferred route
+	Route Route
+
+	// Preferred is the route that takes precedence over Route. Unless
+	// `Router.Precedence` or `mux.WithPriority` are used, it is the route
+	// that was registered first
+	Preferred Route
+}
+
+func (c *Conflict) Error() string {
+	switch c.Kind {
+	case ConflictDuplicate:
+		return fmt.Sprintf(`route %s duplicates route %s`, describeRoute(c.Route), describeRoute(c.Preferred))
+	case ConflictShadowed:
+		return fmt.Sprintf(`route %s is shadowed by route %s`, describeRoute(c.Route), describeRoute(c.Preferred))
+	default:
+		return fmt.Sprintf(`route %s is ambiguous with route %s`, describeRoute(c.Route), describeRoute(c.Preferred))
+	}
+}
+
+func describeRoute(r Route) string {
+	method := r.Method
+	if method == "" {
+		method = `*`
+	}
+	if r.Mount {
+		return fmt.Sprintf(`%s %s (mount)`, method, r.Pattern)
+	}
+	return fmt.Sprintf(`%s %s`, method, r.Pattern)
+}
+
+// Validate analyzes all routes registered in the Router, and returns the
+// conflicts between them, regardless of `Router.Conflicts`. It is meant
+// to be called from tests, to make sure that no route is shadowed by
+// another.
+//
+// The analysis is conservative, and only reports conflicts that can be
+// proven from the structure of the patterns. Routes with conditions
+// other than the path and the method, such as those added using
+// `mux.MatchHeader`, are never reported, and neither are routes with
+// different host patterns.
+func (r *Router) Validate() []*Conflict {
+	t := r.load()
+
+	var conflicts []*Conflict
+	for i, p := range t.ranked {
+		for _, preferred := range t.ranked[:i] {
+			if c := conflictBetween(preferred, p); c != nil {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	return conflicts
+}
+
+// conflicts returns the conflicts between a new route and the routes that
+// have already been registered. The caller must hold the lock
+func (r *Router) conflicts(t *table, p *path) []*Conflict {
+	var conflicts []*Conflict
+	for _, existing := range t.paths {
+		var c *Conflict
+		if r.precedes(p, existing) {
+			c = conflictBetween(p, existing)
+		} else {
+			c = conflictBetween(existing, p)
+		}
+		if c != nil {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+func (r *Router) reportConflicts(conflicts []*Conflict) {
+	for _, c := range conflicts {
+		if r.OnConflict != nil {
+			r.OnConflict(c)
+		} else {
+			log.Printf(`mux: %s`, c)
+		}
+	}
+}
+
+func conflictBetween(preferred, p *path) *Conflict {
+	// routes that respond to different methods never conflict, and
+	// neither do routes with other conditions, as the conditions are
+	// what tells them apart
+	if preferred.method != "" && p.method != "" && preferred.method != p.method {
+		return nil
+	}
+	if preferred.hostPattern != p.hostPattern || len(preferred.predicates) > 0 || len(p.predicates) > 0 {
+		return nil
+	}
+
+	rel := pathmatch.Compare(preferred.matcher, preferred.mount, p.matcher, p.mount)
+	if rel == pathmatch.Disjoint {
+		return nil
+	}
+
+	// a route that responds to any method still handles the methods
+	// that the preferred route does not respond to, so it is never
+	// shadowed, but it shares the requests for the preferred method
+	if preferred.method != "" && p.method == "" {
+		return &Conflict{
+			Kind:      ConflictAmbiguous,
+			Route:     p.route(),
+			Preferred: preferred.route(),
+		}
+	}
+
+	var kind ConflictKind
+	switch rel {
+	case pathmatch.Equivalent:
+		kind = ConflictShadowed
+		if preferred.method == p.method && preferred.mount == p.mount {
+			kind = ConflictDuplicate
+		}
+	case pathmatch.Covers:
+		kind = ConflictShadowed
+	case pathmatch.Overlaps:
+		kind = ConflictAmbiguous
+	default:
+		// when the other route covers the preferred one, the preferred
+		// route is simply more specific, which is how it should be
+		return nil
+	}
+
+	return &Conflict{
+		Kind:      kind,
+		Route:     p.route(),
+		Preferred: preferred.route(),
+	}
+}
@@ -0,0 +1,151 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflicts(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	t.Run("error", func(t *testing.T) {
+		r := mux.Router{Conflicts: mux.ConflictError}
+		require.NoError(t, r.Get(`/users/me`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/users/{id}`, noop), `more generic routes registered later should be accepted`)
+		require.NoError(t, r.Post(`/users/{id}`, noop), `routes for other methods should be accepted`)
+		require.NoError(t, r.Get(`/users/{id:int}/posts`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/users/{id}/{tab}`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/teams/{team}/members`, noop), `r.Get should succeed`)
+
+		testcases := []struct {
+			Pattern string
+			Kind    mux.ConflictKind
+		}{
+			{Pattern: `/users/{name}`, Kind: mux.ConflictDuplicate},
+			{Pattern: `/users/admin`, Kind: mux.ConflictShadowed},
+			{Pattern: `/users/{id:int}`, Kind: mux.ConflictShadowed},
+			{Pattern: `/teams/core/{tab}`, Kind: mux.ConflictAmbiguous},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Pattern, func(t *testing.T) {
+				err := r.Get(tc.Pattern, noop, mux.WithName(tc.Pattern))
+				require.Error(t, err, `r.Get should fail`)
+
+				var conflict *mux.Conflict
+				require.True(t, errors.As(err, &conflict), `error should be a *mux.Conflict`)
+				require.Equal(t, tc.Kind, conflict.Kind, `conflict kind should match`)
+				require.Equal(t, tc.Pattern, conflict.Route.Pattern, `conflict should describe the new route`)
+			})
+		}
+
+		// rejected routes are not registered at all
+		require.Len(t, r.Routes(), 6, `rejected routes should not be registered`)
+		_, err := r.URL(`/users/admin`)
+		require.Error(t, err, `names of rejected routes should not be registered`)
+	})
+	t.Run("warn", func(t *testing.T) {
+		var conflicts []*mux.Conflict
+		r := mux.Router{
+			Conflicts: mux.ConflictWarn,
+			OnConflict: func(c *mux.Conflict) {
+				conflicts = append(conflicts, c)
+			},
+		}
+		require.NoError(t, r.Mount(`/static`, noop), `r.Mount should succeed`)
+		require.NoError(t, r.Get(`/static/app.js`, noop), `r.Get should succeed`)
+		require.Len(t, conflicts, 1, `one conflict should be reported`)
+		require.Equal(t, mux.ConflictShadowed, conflicts[0].Kind, `conflict kind should match`)
+		require.Equal(t, `route GET /static/app.js is shadowed by route * /static (mount)`, conflicts[0].Error(), `conflict message should match`)
+	})
+	t.Run("specificity", func(t *testing.T) {
+		r := mux.Router{Conflicts: mux.ConflictError, Precedence: mux.PrecedenceSpecificity}
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/users/me`, noop), `more specific routes should be accepted in any order`)
+
+		err := r.Get(`/users/{name}`, noop, mux.WithPriority(1))
+		var conflict *mux.Conflict
+		require.True(t, errors.As(err, &conflict), `error should be a *mux.Conflict`)
+		require.Equal(t, `/users/{id}`, conflict.Route.Pattern, `existing route should be reported as shadowed`)
+		require.Equal(t, `/users/{name}`, conflict.Preferred.Pattern, `new route should be reported as preferred`)
+	})
+	t.Run("any method", func(t *testing.T) {
+		testcases := []struct {
+			Name     string
+			Register func(r *mux.Router) error
+			Expected string
+		}{
+			{
+				Name: "specific method first",
+				Register: func(r *mux.Router) error {
+					if err := r.Get(`/users/{id}`, noop); err != nil {
+						return err
+					}
+					return r.Any(`/users/{id}`, noop)
+				},
+				Expected: `route * /users/{id} is ambiguous with route GET /users/{id}`,
+			},
+			{
+				Name: "specific method first with a more generic path",
+				Register: func(r *mux.Router) error {
+					if err := r.Get(`/users/{id}`, noop); err != nil {
+						return err
+					}
+					return r.Any(`/users/me`, noop)
+				},
+				Expected: `route * /users/me is ambiguous with route GET /users/{id}`,
+			},
+			{
+				Name: "any method first",
+				Register: func(r *mux.Router) error {
+					if err := r.Any(`/users/{id}`, noop); err != nil {
+						return err
+					}
+					return r.Get(`/users/{id}`, noop)
+				},
+				Expected: `route GET /users/{id} is shadowed by route * /users/{id}`,
+			},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				r := mux.Router{Conflicts: mux.ConflictError}
+				err := tc.Register(&r)
+				var conflict *mux.Conflict
+				require.True(t, errors.As(err, &conflict), `error should be a *mux.Conflict`)
+				require.Equal(t, tc.Expected, conflict.Error(), `conflict message should match`)
+			})
+		}
+
+		r := mux.Router{Conflicts: mux.ConflictError}
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Any(`/teams/{id}`, noop), `routes for other paths should be accepted`)
+	})
+	t.Run("conditions", func(t *testing.T) {
+		r := mux.Router{Conflicts: mux.ConflictError}
+		require.NoError(t, r.Get(`/items`, noop, mux.MatchQuery(`action`, `list`)), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/items`, noop), `routes with conditions should not conflict`)
+		require.NoError(t, r.Get(`/hosts`, noop, mux.WithHost(`a.example.com`)), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/hosts`, noop, mux.WithHost(`b.example.com`)), `routes for other hosts should not conflict`)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/me`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Any(`/files/{path...}`, noop), `r.Any should succeed`)
+	require.NoError(t, r.Delete(`/files/{name}.{ext}`, noop), `r.Delete should succeed`)
+	require.NoError(t, r.Get(`/health`, noop), `r.Get should succeed`)
+
+	conflicts := r.Validate()
+	require.Len(t, conflicts, 2, `r.Validate should report the conflicts`)
+	require.Equal(t, `route GET /users/me is shadowed by route GET /users/{id}`, conflicts[0].Error(), `conflict message should match`)
+	require.Equal(t, `route DELETE /files/{name}.{ext} is shadowed by route * /files/{path...}`, conflicts[1].Error(), `conflict message should match`)
+}
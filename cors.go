@@ -0,0 +1,139 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy is the interface used by the Router to respond to
+// cross-origin requests. Assign a value to `Router.CORS` to enable it.
+type CORSPolicy interface {
+	// Preflight is called to respond to a CORS preflight request for a
+	// path that matched at least one route. `allowed` contains the HTTP
+	// methods that were registered for the path.
+	Preflight(w http.ResponseWriter, req *http.Request, allowed []string)
+
+	// Apply is called before the matched handler is invoked for a request
+	// carrying an `Origin` header, and should add the relevant CORS headers
+	// to the response.
+	Apply(w http.ResponseWriter, req *http.Request)
+}
+
+// CORS is a simple CORSPolicy driven by static configuration.
+type CORS struct {
+	// AllowedOrigins is the list of origins that are allowed to access
+	// the resources. The special value `*` allows any origin, unless
+	// AllowCredentials is set
+	AllowedOrigins []string
+
+	// AllowedHeaders is the list of request headers that clients are
+	// allowed to use. The special value `*` allows any header
+	AllowedHeaders []string
+
+	// ExposedHeaders is the list of response headers that clients are
+	// allowed to read
+	ExposedHeaders []string
+
+	// MaxAge is the duration for which clients may cache the result
+	// of the preflight request. Zero omits the header
+	MaxAge time.Duration
+
+	// AllowCredentials specifies if clients may send credentials. Since
+	// that lets the allowed origins read authenticated responses, the
+	// origins must then be listed explicitly: the special value `*` in
+	// AllowedOrigins does not match any origin
+	AllowCredentials bool
+}
+
+func (c *CORS) Preflight(w http.ResponseWriter, req *http.Request, allowed []string) {
+	hdr := w.Header()
+	hdr.Add(`Vary`, `Origin`)
+	hdr.Add(`Vary`, `Access-Control-Request-Method`)
+	hdr.Add(`Vary`, `Access-Control-Request-Headers`)
+
+	origin := req.Header.Get(`Origin`)
+	if !c.allowOrigin(origin) || !containsFold(allowed, req.Header.Get(`Access-Control-Request-Method`)) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var requested []string
+	for _, name := range strings.Split(req.Header.Get(`Access-Control-Request-Headers`), `,`) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !containsFold(c.AllowedHeaders, `*`) && !containsFold(c.AllowedHeaders, name) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		requested = append(requested, name)
+	}
+
+	c.setOrigin(hdr, origin)
+	hdr.Set(`Access-Control-Allow-Methods`, strings.Join(allowed, `, `))
+	if len(requested) > 0 {
+		hdr.Set(`Access-Control-Allow-Headers`, strings.Join(requested, `, `))
+	}
+	if c.MaxAge > 0 {
+		hdr.Set(`Access-Control-Max-Age`, strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) Apply(w http.ResponseWriter, req *http.Request) {
+	hdr := w.Header()
+	hdr.Add(`Vary`, `Origin`)
+
+	origin := req.Header.Get(`Origin`)
+	if !c.allowOrigin(origin) {
+		return
+	}
+
+	c.setOrigin(hdr, origin)
+	if len(c.ExposedHeaders) > 0 {
+		hdr.Set(`Access-Control-Expose-Headers`, strings.Join(c.ExposedHeaders, `, `))
+	}
+}
+
+func (c *CORS) allowOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == `*` && !c.AllowCredentials || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) setOrigin(hdr http.Header, origin string) {
+	// the wildcard is never used with credentials, in which case the
+	// origin was listed explicitly
+	if !c.AllowCredentials && containsFold(c.AllowedOrigins, `*`) {
+		hdr.Set(`Access-Control-Allow-Origin`, `*`)
+	} else {
+		hdr.Set(`Access-Control-Allow-Origin`, origin)
+	}
+	if c.AllowCredentials {
+		hdr.Set(`Access-Control-Allow-Credentials`, `true`)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions &&
+		req.Header.Get(`Origin`) != "" &&
+		req.Header.Get(`Access-Control-Request-Method`) != ""
+}
@@ -0,0 +1,121 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	r := mux.Router{
+		CORS: &mux.CORS{
+			AllowedOrigins:   []string{`https://example.com`},
+			AllowedHeaders:   []string{`Content-Type`, `Authorization`},
+			ExposedHeaders:   []string{`X-Request-Id`},
+			MaxAge:           10 * time.Minute,
+			AllowCredentials: true,
+		},
+	}
+	require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Put(`/users/{id}`, noop), `r.Put should succeed`)
+
+	preflight := func(origin, method, headers string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, `/users/123`, nil)
+		req.Header.Set(`Origin`, origin)
+		req.Header.Set(`Access-Control-Request-Method`, method)
+		if headers != "" {
+			req.Header.Set(`Access-Control-Request-Headers`, headers)
+		}
+		return req
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preflight(`https://example.com`, http.MethodPut, `content-type, authorization`))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+
+		hdr := w.Header()
+		require.Equal(t, `https://example.com`, hdr.Get(`Access-Control-Allow-Origin`))
+		require.Equal(t, `GET, OPTIONS, PUT`, hdr.Get(`Access-Control-Allow-Methods`))
+		require.Equal(t, `content-type, authorization`, hdr.Get(`Access-Control-Allow-Headers`))
+		require.Equal(t, `600`, hdr.Get(`Access-Control-Max-Age`))
+		require.Equal(t, `true`, hdr.Get(`Access-Control-Allow-Credentials`))
+	})
+	t.Run("preflight with disallowed origin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preflight(`https://evil.example.com`, http.MethodPut, ``))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+		require.Empty(t, w.Header().Get(`Access-Control-Allow-Origin`))
+	})
+	t.Run("preflight with disallowed method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preflight(`https://example.com`, http.MethodDelete, ``))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+		require.Empty(t, w.Header().Get(`Access-Control-Allow-Origin`))
+	})
+	t.Run("preflight with disallowed header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, preflight(`https://example.com`, http.MethodPut, `X-Custom`))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+		require.Empty(t, w.Header().Get(`Access-Control-Allow-Origin`))
+	})
+	t.Run("actual request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, `/users/123`, nil)
+		req.Header.Set(`Origin`, `https://example.com`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, `status code should be 200`)
+		require.Equal(t, `https://example.com`, w.Header().Get(`Access-Control-Allow-Origin`))
+		require.Equal(t, `X-Request-Id`, w.Header().Get(`Access-Control-Expose-Headers`))
+	})
+	t.Run("wildcard with credentials", func(t *testing.T) {
+		r := mux.Router{
+			CORS: &mux.CORS{
+				AllowedOrigins:   []string{`*`, `https://example.com`},
+				AllowCredentials: true,
+			},
+		}
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+
+		for origin, expected := range map[string]string{`https://evil.example.com`: ``, `https://example.com`: `https://example.com`} {
+			req := httptest.NewRequest(http.MethodGet, `/users/123`, nil)
+			req.Header.Set(`Origin`, origin)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, expected, w.Header().Get(`Access-Control-Allow-Origin`), `the wildcard should not allow %s when credentials are allowed`, origin)
+
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, preflight(origin, http.MethodGet, ``))
+			require.Equal(t, expected, w.Header().Get(`Access-Control-Allow-Origin`), `the wildcard should not allow a preflight from %s`, origin)
+		}
+	})
+	t.Run("wildcard without credentials", func(t *testing.T) {
+		r := mux.Router{CORS: &mux.CORS{AllowedOrigins: []string{`*`}}}
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+
+		req := httptest.NewRequest(http.MethodGet, `/users/123`, nil)
+		req.Header.Set(`Origin`, `https://anywhere.example.com`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, `*`, w.Header().Get(`Access-Control-Allow-Origin`))
+		require.Empty(t, w.Header().Get(`Access-Control-Allow-Credentials`))
+	})
+	t.Run("plain OPTIONS without AutoOptions", func(t *testing.T) {
+		// OPTIONS is listed in the Allow header, so it must be answered
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, `/users/123`, nil))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+		require.Equal(t, `GET, OPTIONS, PUT`, w.Header().Get(`Allow`))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, `/users/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `GET, OPTIONS, PUT`, w.Header().Get(`Allow`))
+	})
+}
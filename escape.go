@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// matchPath returns the path of the request that routes are matched
+// against.
+//
+// When `Router.MatchEscapedPath` is enabled, the escaped path of the
+// request is decoded one segment at a time, and percent signs and slashes
+// within a segment are left escaped as `%25` and `%2F`. This keeps encoded
+// slashes from being mistaken for segment separators, while literal
+// components of patterns can still be written in their decoded form
+//
+// When `Router.NormalizeUnicode` is enabled, the path is normalized to NFC
+func (r *Router) matchPath(req *http.Request) string {
+	p := r.escapedMatchPath(req)
+	if r.NormalizeUnicode {
+		p = norm.NFC.String(p)
+	}
+	return p
+}
+
+func (r *Router) escapedMatchPath(req *http.Request) string {
+	if !r.MatchEscapedPath {
+		return req.URL.Path
+	}
+
+	// without RawPath, the path did not contain any encoded slashes
+	if req.URL.RawPath == "" && !strings.Contains(req.URL.Path, `%`) {
+		return req.URL.Path
+	}
+
+	segments := strings.Split(req.URL.EscapedPath(), `/`)
+	for i, segment := range segments {
+		if !strings.Contains(segment, `%`) {
+			continue
+		}
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			// EscapedPath never returns invalid escapes
+			continue
+		}
+		segments[i] = segmentEscaper.Replace(decoded)
+	}
+	return strings.Join(segments, `/`)
+}
+
+var segmentEscaper = strings.NewReplacer(`%`, `%25`, `/`, `%2F`)
+var segmentUnescaper = strings.NewReplacer(`%25`, `%`, `%2F`, `/`)
+
+// unescapeMatchPath decodes a path, or a portion of a path, that was
+// returned by `matchPath`
+func (r *Router) unescapeMatchPath(s string) string {
+	if !r.MatchEscapedPath || !strings.Contains(s, `%`) {
+		return s
+	}
+	return segmentUnescaper.Replace(s)
+}
+
+// escapeMatchPath returns the escaped form of a path, or a portion of a
+// path, that was returned by `matchPath`, suitable for use in a URL
+func (r *Router) escapeMatchPath(s string) string {
+	if !r.MatchEscapedPath {
+		u := url.URL{Path: s}
+		return u.EscapedPath()
+	}
+
+	segments := strings.Split(s, `/`)
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(r.unescapeMatchPath(segment))
+	}
+	return strings.Join(segments, `/`)
+}
+
+// setMatchPath replaces the path of the URL with a path that is in the
+// form returned by `matchPath`
+func (r *Router) setMatchPath(u *url.URL, s string) {
+	u.Path = r.unescapeMatchPath(s)
+	u.RawPath = ""
+	if r.MatchEscapedPath && strings.Contains(s, `%2F`) {
+		u.RawPath = r.escapeMatchPath(s)
+	}
+}
@@ -0,0 +1,163 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Group is a set of routes that share a common path prefix. Groups are
+// created using `Router.Group`, and the routes declared through a Group
+// are registered in the Router that created it.
+//
+// The prefix is prepended to the patterns of the routes before they are
+// parsed, so it may contain variable components just like any other
+// pattern.
+type Group struct {
+	router      *Router
+	parent      *Group
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group creates a new Group, whose routes are prefixed with `prefix`.
+func (r *Router) Group(prefix string) *Group {
+	return &Group{
+		router: r,
+		prefix: prefix,
+	}
+}
+
+// Mount delegates all requests whose path starts with `prefix` to `hh`,
+// regardless of the HTTP method. The prefix must end at a path boundary:
+// `/api` matches `/api` and `/api/users`, but not `/apis`.
+//
+// The prefix is removed from the path of the request before it is passed
+// to `hh`. If `hh` is a `*Router`, the variables captured in the prefix
+// are available through `mux.Vars` alongside its own variables.
+func (r *Router) Mount(prefix string, hh http.Handler, options ...RouteOption) error {
+	return r.add(&path{handler: hh, mount: true}, prefix, options)
+}
+
+// Group creates a new Group, whose routes are prefixed with the prefix
+// of the current group followed by `prefix`. The middlewares of the
+// current group also apply to the new group.
+func (g *Group) Group(prefix string) *Group {
+	return &Group{
+		router: g.router,
+		parent: g,
+		prefix: g.prefix + prefix,
+	}
+}
+
+// Use adds middlewares that are applied to the routes of the group,
+// including routes in nested groups. They are invoked after the
+// middlewares of the Router and of the parent groups, and before
+// the middlewares specific to each route.
+func (g *Group) Use(middlewares ...Middleware) {
+	g.router.mu.Lock()
+	defer g.router.mu.Unlock()
+	g.middlewares = append(g.middlewares, middlewares...)
+	g.router.republish()
+}
+
+// Mount is the same as `Router.Mount`, with the prefix of the group
+// prepended to `prefix`.
+func (g *Group) Mount(prefix string, hh http.Handler, options ...RouteOption) error {
+	return g.router.add(&path{handler: hh, mount: true, group: g}, g.prefix+prefix, options)
+}
+
+// Handler is the same as `Router.Handler`, with the prefix of the group
+// prepended to `pattern`.
+func (g *Group) Handler(method string, pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.router.add(&path{method: method, handler: hh, group: g}, g.prefix+pattern, options)
+}
+
+// Any declares an endpoint that responds to HTTP requests with
+// any HTTP verbs in the specified path pattern
+func (g *Group) Any(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler("", pattern, hh, options...)
+}
+
+// Get declares an endpoint that responds to HTTP GET requests
+// in the specified path pattern
+func (g *Group) Get(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodGet, pattern, hh, options...)
+}
+
+// Head declares an endpoint that responds to HTTP HEAD requests
+// in the specified path pattern
+func (g *Group) Head(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodHead, pattern, hh, options...)
+}
+
+// Post declares an endpoint that responds to HTTP POST requests
+// in the specified path pattern
+func (g *Group) Post(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodPost, pattern, hh, options...)
+}
+
+// Put declares an endpoint that responds to HTTP PUT requests
+// in the specified path pattern
+func (g *Group) Put(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodPut, pattern, hh, options...)
+}
+
+// Patch declares an endpoint that responds to HTTP PATCH requests
+// in the specified path pattern
+func (g *Group) Patch(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodPatch, pattern, hh, options...)
+}
+
+// Delete declares an endpoint that responds to HTTP DELETE requests
+// in the specified path pattern
+func (g *Group) Delete(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodDelete, pattern, hh, options...)
+}
+
+// Connect declares an endpoint that responds to HTTP CONNECT requests
+// in the specified path pattern
+func (g *Group) Connect(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodConnect, pattern, hh, options...)
+}
+
+// Options declares an endpoint that responds to HTTP OPTIONS requests
+// in the specified path pattern
+func (g *Group) Options(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodOptions, pattern, hh, options...)
+}
+
+// Trace declares an endpoint that responds to HTTP TRACE requests
+// in the specified path pattern
+func (g *Group) Trace(pattern string, hh http.Handler, options ...RouteOption) error {
+	return g.Handler(http.MethodTrace, pattern, hh, options...)
+}
+
+// stripPrefix returns a shallow copy of the request, whose path has
+// been replaced with the portion of `p` that was not consumed by a mount.
+// `p` is the path that the mount was matched against
+func (r *Router) stripPrefix(req *http.Request, p, rest string) *http.Request {
+	// the portion of the path consumed by the mount is recorded, so that
+	// the mounted router can build paths that the client understands
+	prefix := strings.TrimSuffix(p[:len(p)-len(rest)], `/`)
+	prefix = mountPrefix(req) + r.escapeMatchPath(prefix)
+
+	if !strings.HasPrefix(rest, `/`) {
+		rest = `/` + rest
+	}
+
+	r2 := req.WithContext(context.WithValue(req.Context(), identMountPrefix{}, prefix))
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	r.setMatchPath(r2.URL, rest)
+	return r2
+}
+
+// mountPrefix returns the escaped portion of the original path of the
+// request that was consumed by the mounts that the request was dispatched
+// through
+func mountPrefix(req *http.Request) string {
+	prefix, _ := req.Context().Value(identMountPrefix{}).(string)
+	return prefix
+}
@@ -0,0 +1,98 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		fmt.Fprintf(w, `%s %s tenant=%s id=%s`, r.Method, r.URL.Path, vars.Get(`tenant`), vars.Get(`id`))
+	})
+
+	var r mux.Router
+	api := r.Group(`/api/v1`)
+	require.NoError(t, api.Get(`/users/{id}`, echo), `api.Get should succeed`)
+
+	tenants := api.Group(`/tenants/{tenant}`)
+	require.NoError(t, tenants.Get(`/users/{id:^[0-9]+$}`, echo), `tenants.Get should succeed`)
+
+	testcases := []struct {
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{Path: `/api/v1/users/123`, Status: http.StatusOK, Expected: `GET /api/v1/users/123 tenant= id=123`},
+		{Path: `/api/v1/tenants/acme/users/123`, Status: http.StatusOK, Expected: `GET /api/v1/tenants/acme/users/123 tenant=acme id=123`},
+		{Path: `/api/v1/tenants/acme/users/abc`, Status: http.StatusNotFound},
+		{Path: `/users/123`, Status: http.StatusNotFound},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			}
+		})
+	}
+
+	t.Run("invalid prefix", func(t *testing.T) {
+		require.Error(t, r.Group(`/{unterminated`).Get(`/foo`, echo), `registering a route with an invalid prefix should fail`)
+	})
+}
+
+func TestMount(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		fmt.Fprintf(w, `%s tenant=%s id=%s`, r.URL.Path, vars.Get(`tenant`), vars.Get(`id`))
+	})
+
+	var users mux.Router
+	require.NoError(t, users.Get(`/`, echo), `users.Get should succeed`)
+	require.NoError(t, users.Get(`/{id}`, echo), `users.Get should succeed`)
+	require.NoError(t, users.Delete(`/{id}`, echo), `users.Delete should succeed`)
+
+	var root mux.Router
+	require.NoError(t, root.Get(`/tenants/{tenant}/users/me`, echo), `root.Get should succeed`)
+	require.NoError(t, root.Mount(`/tenants/{tenant}/users`, &users), `root.Mount should succeed`)
+	require.NoError(t, root.Group(`/static`).Mount(`/`, echo), `group.Mount should succeed`)
+
+	testcases := []struct {
+		Method   string
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{Path: `/tenants/acme/users`, Status: http.StatusOK, Expected: `/ tenant=acme id=`},
+		{Path: `/tenants/acme/users/`, Status: http.StatusOK, Expected: `/ tenant=acme id=`},
+		{Path: `/tenants/acme/users/123`, Status: http.StatusOK, Expected: `/123 tenant=acme id=123`},
+		{Path: `/tenants/acme/users/me`, Status: http.StatusOK, Expected: `/tenants/acme/users/me tenant=acme id=`},
+		{Path: `/tenants/acme/usersx`, Status: http.StatusNotFound},
+		{Path: `/tenants/acme/users/123/posts`, Status: http.StatusNotFound},
+		{Method: http.MethodPost, Path: `/tenants/acme/users/123`, Status: http.StatusMethodNotAllowed},
+		{Path: `/static/css/main.css`, Status: http.StatusOK, Expected: `/css/main.css tenant= id=`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		if tc.Method == "" {
+			tc.Method = http.MethodGet
+		}
+		t.Run(fmt.Sprintf("%s %s", tc.Method, tc.Path), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			root.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			}
+		})
+	}
+}
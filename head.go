@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headResponseWriter is used when a HEAD request is dispatched to a
+// handler registered for GET. The body written by the handler is
+// discarded, but its length is reported in the `Content-Length` header,
+// unless the handler has set it explicitly.
+//
+// Flushing sends the headers right away, in which case the length of the
+// body is not known, and `Content-Length` is only sent if the handler
+// has set it.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	written   int
+	committed bool
+}
+
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.written += len(b)
+	return len(b), nil
+}
+
+// Flush sends the status code and headers to the underlying
+// http.ResponseWriter, and flushes it if it supports flushing
+func (w *headResponseWriter) Flush() {
+	if !w.committed {
+		w.commit()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for use by
+// http.ResponseController
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish sends the status code and headers to the underlying
+// http.ResponseWriter, unless they were already sent by Flush. It must
+// be called after the handler returns
+func (w *headResponseWriter) finish() {
+	if w.committed {
+		return
+	}
+	if w.written > 0 && bodyAllowedForStatus(w.status) {
+		hdr := w.Header()
+		if hdr.Get(`Content-Length`) == "" {
+			hdr.Set(`Content-Length`, strconv.Itoa(w.written))
+		}
+	}
+	w.commit()
+}
+
+func (w *headResponseWriter) commit() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.committed = true
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHost(t *testing.T) {
+	echo := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			fmt.Fprintf(w, `%s tenant=%s id=%s`, name, vars.Get(`tenant`), vars.Get(`id`))
+		})
+	}
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/users/{id}`, echo(`admin`), mux.WithHost(`admin.example.com`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id}`, echo(`tenant`), mux.WithHost(`{tenant}.example.com`)), `r.Get should succeed`)
+	require.NoError(t, r.Delete(`/users/{id}`, echo(`tenant`), mux.WithHost(`{tenant:^[a-z]+}.example.com`)), `r.Delete should succeed`)
+	require.NoError(t, r.Get(`/users/{id}`, echo(`any`)), `r.Get should succeed`)
+
+	require.Error(t, r.Get(`/{tenant}`, echo(`dup`), mux.WithHost(`{tenant}.example.com`)), `duplicate variable names should be rejected`)
+	require.Error(t, r.Get(`/`, echo(`invalid`), mux.WithHost(`{tenant`)), `invalid host patterns should be rejected`)
+
+	testcases := []struct {
+		Method   string
+		Host     string
+		Status   int
+		Expected string
+	}{
+		{Host: `admin.example.com`, Status: http.StatusOK, Expected: `admin tenant= id=123`},
+		{Host: `ACME.example.com:8080`, Status: http.StatusOK, Expected: `tenant tenant=acme id=123`},
+		{Host: `acme.sub.example.com`, Status: http.StatusOK, Expected: `any tenant= id=123`},
+		{Host: `localhost`, Status: http.StatusOK, Expected: `any tenant= id=123`},
+		{Method: http.MethodDelete, Host: `acme.example.com`, Status: http.StatusOK, Expected: `tenant tenant=acme id=123`},
+		{Method: http.MethodDelete, Host: `localhost`, Status: http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		if tc.Method == "" {
+			tc.Method = http.MethodGet
+		}
+		t.Run(fmt.Sprintf("%s %s", tc.Method, tc.Host), func(t *testing.T) {
+			req := httptest.NewRequest(tc.Method, `/users/123`, nil)
+			req.Host = tc.Host
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			}
+		})
+	}
+}
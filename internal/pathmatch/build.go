@@ -0,0 +1,169 @@
+package pathmatch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Build renders the pattern back into a path, substituting the variable
+// components with the given values. The returned path is escaped, and
+// is suitable for use in a URL.
+//
+// An error is returned if a value is missing, if a value does not satisfy
+// the constraints of its variable, or if the resulting path would not be
+// matched by the pattern with the same values.
+//
+// Optional sections are only rendered if values are given for their
+// variables, and are left out otherwise.
+func (p *Matcher) Build(values map[string]string) (string, error) {
+	return p.buildPath(values, false)
+}
+
+// BuildEscaped is like Build, but for paths that are matched in their
+// escaped form, where slashes and percent signs within a path segment
+// appear as `%2F` and `%25` respectively. Values for variables of the
+// form `{name}` may then contain slashes, which are escaped as `%2F`.
+func (p *Matcher) BuildEscaped(values map[string]string) (string, error) {
+	return p.buildPath(values, true)
+}
+
+func (p *Matcher) buildPath(values map[string]string, escaped bool) (string, error) {
+	var b builder
+	b.values = values
+	b.used = make(map[string]string)
+	b.escapeSegments = escaped
+	if err := p.build(&b, p.exprs); err != nil {
+		return "", err
+	}
+
+	for name := range values {
+		if _, ok := b.used[name]; !ok {
+			return "", fmt.Errorf(`unknown variable %q`, name)
+		}
+	}
+
+	// make sure that the path we built is actually matched by the pattern,
+	// with the same values that we were given. Values that were not given
+	// may be filled in with defaults, so they are not compared
+	mv, err := p.Match(b.raw.String())
+	if err != nil {
+		return "", fmt.Errorf(`generated path %q does not match the pattern: %w`, b.raw.String(), err)
+	}
+	for name, v := range b.used {
+		if mv[name] != v {
+			return "", fmt.Errorf(`invalid value for variable %q: generated path %q would match with value %q`, name, b.raw.String(), mv[name])
+		}
+	}
+	return b.escaped.String(), nil
+}
+
+type builder struct {
+	values map[string]string
+	// used holds the values of the variables as they were written to raw
+	used map[string]string
+	// escapeSegments writes slashes and percent signs in values of the
+	// form `{name}` to raw as `%2F` and `%25`
+	escapeSegments bool
+	raw            strings.Builder
+	escaped        strings.Builder
+}
+
+func (p *Matcher) build(b *builder, exprs []Expression) error {
+	for _, expr := range exprs {
+		switch expr := expr.(type) {
+		case *Literal:
+			b.raw.WriteString(expr.Lit)
+			b.escaped.WriteString(escapePath(expr.Lit))
+		case *Optional:
+			// optional sections are left out if no values are given for
+			// any of their variables, including those in nested sections.
+			// Otherwise, values must be given for all of the variables
+			// that are not in nested sections
+			var given []string
+			for _, v := range variables(expr.Exprs) {
+				if _, ok := b.values[v.Name]; ok {
+					given = append(given, v.Name)
+				}
+			}
+			if len(given) == 0 {
+				continue
+			}
+			for _, sub := range expr.Exprs {
+				v, ok := sub.(variableExpression)
+				if !ok {
+					continue
+				}
+				name := v.variable().Name
+				if _, ok := b.values[name]; !ok {
+					return fmt.Errorf(`missing value for variable %q: values for %q were given in the same optional section`, name, given)
+				}
+			}
+			if err := p.build(b, expr.Exprs); err != nil {
+				return err
+			}
+		case *LiteralPattern:
+			v, ok := b.values[expr.Name]
+			if !ok {
+				return fmt.Errorf(`missing value for variable %q`, expr.Name)
+			}
+			if v == "" {
+				return fmt.Errorf(`invalid value for variable %q: value must be a non-empty string`, expr.Name)
+			}
+			raw := v
+			if b.escapeSegments {
+				raw = segmentEscaper.Replace(v)
+			} else if strings.IndexByte(v, '/') > -1 {
+				return fmt.Errorf(`invalid value for variable %q: value must be a non-empty string without slashes`, expr.Name)
+			}
+			b.used[expr.Name] = raw
+			b.raw.WriteString(raw)
+			b.escaped.WriteString(url.PathEscape(v))
+		case *TypedPattern:
+			v, ok := b.values[expr.Name]
+			if !ok {
+				return fmt.Errorf(`missing value for variable %q`, expr.Name)
+			}
+			if rest, err := p.consumers[expr].Consume(v, make(Values)); err != nil || rest != "" {
+				return fmt.Errorf(`invalid value for variable %q: value is not a valid %s`, expr.Name, expr.Type)
+			}
+			b.used[expr.Name] = v
+			b.raw.WriteString(v)
+			b.escaped.WriteString(url.PathEscape(v))
+		case *RegexpPattern:
+			v, ok := b.values[expr.Name]
+			if !ok {
+				return fmt.Errorf(`missing value for variable %q`, expr.Name)
+			}
+			if rest, err := p.consumers[expr].Consume(v, make(Values)); err != nil || rest != "" {
+				return fmt.Errorf(`invalid value for variable %q: value does not satisfy pattern %q`, expr.Name, expr.Pattern)
+			}
+			b.used[expr.Name] = v
+			b.raw.WriteString(v)
+			b.escaped.WriteString(escapePath(v))
+		case *Wildcard:
+			// wildcards may hold any value, including slashes
+			v, ok := b.values[expr.Name]
+			if !ok {
+				return fmt.Errorf(`missing value for variable %q`, expr.Name)
+			}
+			b.used[expr.Name] = v
+			b.raw.WriteString(v)
+			b.escaped.WriteString(escapePath(v))
+		default:
+			return fmt.Errorf(`invalid expression %T`, expr)
+		}
+	}
+	return nil
+}
+
+// escapePath escapes each segment of the path, preserving the slashes
+func escapePath(s string) string {
+	segments := strings.Split(s, `/`)
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, `/`)
+}
+
+var segmentEscaper = strings.NewReplacer(`%`, `%25`, `/`, `%2F`)
@@ -0,0 +1,336 @@
+package pathmatch
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Relation describes how the sets of inputs matched by two Matchers
+// relate to each other.
+type Relation int
+
+const (
+	// Disjoint means that no input is known to be matched by both
+	Disjoint Relation = iota
+	// Overlaps means that some, but not all, inputs are matched by both
+	Overlaps
+	// Covers means that every input matched by the second Matcher is
+	// also matched by the first
+	Covers
+	// CoveredBy means that every input matched by the first Matcher is
+	// also matched by the second
+	CoveredBy
+	// Equivalent means that both Matchers match the same inputs
+	Equivalent
+)
+
+func (r Relation) String() string {
+	switch r {
+	case Disjoint:
+		return `disjoint`
+	case Overlaps:
+		return `overlaps`
+	case Covers:
+		return `covers`
+	case CoveredBy:
+		return `covered by`
+	case Equivalent:
+		return `equivalent`
+	default:
+		return `unknown`
+	}
+}
+
+// Compare analyzes the inputs matched by two Matchers. If aPrefix or
+// bPrefix is true, the corresponding Matcher is treated as a prefix,
+// as if it were inserted into a Tree using InsertPrefix.
+//
+// The analysis is conservative: a relation is only reported if it can
+// be proven by looking at the structure of the patterns. For example,
+// `{id:int}` and `{id:hex}` are reported as disjoint, even though both
+// match `123`.
+func Compare(a *Matcher, aPrefix bool, b *Matcher, bPrefix bool) Relation {
+	as := a.sequences(aPrefix)
+	bs := b.sequences(bPrefix)
+
+	ab := coversAll(as, bs)
+	ba := coversAll(bs, as)
+	switch {
+	case ab && ba:
+		return Equivalent
+	case ab:
+		return Covers
+	case ba:
+		return CoveredBy
+	}
+
+	for _, x := range as {
+		for _, y := range bs {
+			if overlapsSequence(x, y) {
+				return Overlaps
+			}
+		}
+	}
+	return Disjoint
+}
+
+// segment describes the inputs matched by a single segment of a pattern.
+type segment struct {
+	// lit is the text of a segment without variables
+	lit string
+
+	// fold is true if lit is matched regardless of case, in which case
+	// lit is in folded form
+	fold bool
+
+	// parts are the components of a segment with variables. It is nil
+	// for segments without variables
+	parts []consumer
+	key   string
+	sep   byte
+}
+
+var wildcardSegment = segment{
+	parts: []consumer{&wildcardConsumer{}},
+	key:   `wildcard`,
+}
+
+// wildcard returns true if the segment is a wildcard on its own, which
+// matches the remainder of the input
+func (s segment) wildcard() bool {
+	if len(s.parts) != 1 {
+		return false
+	}
+	_, ok := s.parts[0].(*wildcardConsumer)
+	return ok
+}
+
+// match returns true if the segment matches the text of a segment
+func (s segment) match(lit string) bool {
+	if s.parts == nil {
+		if s.fold {
+			return s.lit == foldString(lit)
+		}
+		return s.lit == lit
+	}
+	if len(s.parts) == 1 {
+		rest, err := s.parts[0].Consume(lit, make(Values))
+		return err == nil && rest == ""
+	}
+	return matchParts(s.parts, lit, s.sep, make(Values))
+}
+
+// unbounded returns true if the segment may match any input that
+// contains a separator, or that is empty
+func (s segment) unbounded() bool {
+	for _, part := range s.parts {
+		switch part.(type) {
+		case *wildcardConsumer, *regexpConsumer:
+			return true
+		}
+	}
+	return s.match("")
+}
+
+// plain returns true if the segment is a variable of the form `{name}`
+func (s segment) plain() bool {
+	if len(s.parts) != 1 {
+		return false
+	}
+	_, ok := s.parts[0].(*segmentConsumer)
+	return ok
+}
+
+func (s segment) covers(o segment) bool {
+	switch {
+	case s.parts == nil:
+		return o.parts == nil && s.coversLiteral(o)
+	case o.parts == nil:
+		if o.fold && !caseless(o.lit) {
+			// only plain variables are known to match every case
+			return s.plain() && s.match(o.lit)
+		}
+		return s.match(o.lit)
+	case s.key == o.key:
+		return true
+	case s.plain():
+		return !o.unbounded()
+	}
+	return false
+}
+
+// coversLiteral returns true if the literal segment matches every input
+// that the literal segment `o` matches
+func (s segment) coversLiteral(o segment) bool {
+	switch {
+	case s.fold:
+		return s.lit == foldString(o.lit)
+	case o.fold:
+		return s.lit == o.lit && caseless(o.lit)
+	default:
+		return s.lit == o.lit
+	}
+}
+
+func (s segment) overlaps(o segment) bool {
+	switch {
+	case s.parts == nil && o.parts == nil && (s.fold || o.fold):
+		return foldString(s.lit) == foldString(o.lit)
+	case s.parts == nil:
+		return o.match(s.lit)
+	case o.parts == nil:
+		return s.match(o.lit)
+	case s.key == o.key:
+		return true
+	case s.plain():
+		return !o.unbounded()
+	case o.plain():
+		return !s.unbounded()
+	}
+	return false
+}
+
+func coversAll(as, bs [][]segment) bool {
+	for _, b := range bs {
+		var covered bool
+		for _, a := range as {
+			if coversSequence(a, b) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func coversSequence(a, b []segment) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].wildcard() {
+			return true
+		}
+		if b[i].wildcard() || !a[i].covers(b[i]) {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+func overlapsSequence(a, b []segment) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].wildcard() || b[i].wildcard() {
+			return true
+		}
+		if !a[i].overlaps(b[i]) {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+// sequences splits every variant of the Matcher into segments. If prefix
+// is true, the sequences are extended to match any input that starts
+// with a match at a path boundary
+func (p *Matcher) sequences(prefix bool) [][]segment {
+	var result [][]segment
+	for _, v := range p.variants {
+		if prefix {
+			// unless the pattern ends with a separator, a prefix also
+			// matches the input that ends right after the match
+			if seq := splitSegments(v.consumers, p.sep); !endsWithSeparator(seq) {
+				result = append(result, seq)
+			}
+		}
+		result = append(result, p.sequence(v, prefix))
+	}
+	return result
+}
+
+// sequence splits a single variant into segments. If prefix is true,
+// the sequence ends with a wildcard
+func (p *Matcher) sequence(v *variant, prefix bool) []segment {
+	seq := splitSegments(v.consumers, p.sep)
+	if !prefix {
+		return seq
+	}
+	if endsWithSeparator(seq) {
+		seq[len(seq)-1] = wildcardSegment
+		return seq
+	}
+	return withWildcard(seq)
+}
+
+func endsWithSeparator(seq []segment) bool {
+	last := seq[len(seq)-1]
+	return last.parts == nil && last.lit == ""
+}
+
+func withWildcard(seq []segment) []segment {
+	extended := make([]segment, len(seq), len(seq)+1)
+	copy(extended, seq)
+	return append(extended, wildcardSegment)
+}
+
+func splitSegments(consumers []consumer, sep byte) []segment {
+	var result []segment
+	var parts []consumer
+	flush := func() {
+		var lit strings.Builder
+		var keys []string
+		dynamic, fold := false, false
+		for _, part := range parts {
+			switch l := part.(type) {
+			case literalConsumer:
+				lit.WriteString(string(l))
+				keys = append(keys, strconv.Quote(string(l)))
+				continue
+			case foldLiteralConsumer:
+				lit.WriteString(string(l))
+				fold = true
+			default:
+				dynamic = true
+			}
+			keys = append(keys, consumerKey(part))
+		}
+
+		if dynamic {
+			result = append(result, segment{parts: parts, key: strings.Join(keys, `,`), sep: sep})
+		} else {
+			result = append(result, segment{lit: lit.String(), fold: fold})
+		}
+		parts = nil
+	}
+
+	for _, c := range consumers {
+		switch c := c.(type) {
+		case literalConsumer:
+			pieces := strings.Split(string(c), string(sep))
+			for i, piece := range pieces {
+				if i > 0 {
+					flush()
+				}
+				if piece != "" {
+					parts = append(parts, literalConsumer(piece))
+				}
+			}
+		case foldLiteralConsumer:
+			pieces := strings.Split(string(c), string(sep))
+			for i, piece := range pieces {
+				if i > 0 {
+					flush()
+				}
+				if piece != "" {
+					parts = append(parts, foldLiteralConsumer(piece))
+				}
+			}
+		case *compoundConsumer:
+			parts = append(parts, c.parts...)
+		default:
+			parts = append(parts, c)
+		}
+	}
+	flush()
+	return result
+}
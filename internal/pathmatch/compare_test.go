@@ -0,0 +1,122 @@
+package pathmatch_test
+
+import (
+	"testing"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	testcases := []struct {
+		A        string
+		APrefix  bool
+		B        string
+		BPrefix  bool
+		Expected pathmatch.Relation
+	}{
+		{A: `/users/{id}`, B: `/users/{name}`, Expected: pathmatch.Equivalent},
+		{A: `/users/{id}`, B: `/users/me`, Expected: pathmatch.Covers},
+		{A: `/users/me`, B: `/users/{id}`, Expected: pathmatch.CoveredBy},
+		{A: `/users/{id}`, B: `/users/{id:int}`, Expected: pathmatch.Covers},
+		{A: `/users/{id:int}`, B: `/users/123`, Expected: pathmatch.Covers},
+		{A: `/users/{id:int}`, B: `/users/me`, Expected: pathmatch.Disjoint},
+		{A: `/users/{id:int}`, B: `/users/{id:alpha}`, Expected: pathmatch.Disjoint},
+		{A: `/users/{id}`, B: `/users/{id}/posts`, Expected: pathmatch.Disjoint},
+		{A: `/users/{id}/posts`, B: `/users/me/{tab}`, Expected: pathmatch.Overlaps},
+		{A: `/files/{path...}`, B: `/files/css/main.css`, Expected: pathmatch.Covers},
+		{A: `/files/{path...}`, B: `/files`, Expected: pathmatch.Disjoint},
+		{A: `/files/{name}`, B: `/files/{name}.{ext}`, Expected: pathmatch.Covers},
+		{A: `/posts[/{page}]`, B: `/posts`, Expected: pathmatch.Covers},
+		{A: `/posts[/{page}]`, B: `/posts/{id}`, Expected: pathmatch.Covers},
+		{A: `/api`, APrefix: true, B: `/api/users`, Expected: pathmatch.Covers},
+		{A: `/api`, APrefix: true, B: `/api`, Expected: pathmatch.Covers},
+		{A: `/api`, APrefix: true, B: `/apis`, Expected: pathmatch.Disjoint},
+		{A: `/`, APrefix: true, B: `/anything/{id}`, Expected: pathmatch.Covers},
+		{A: `/api/`, APrefix: true, B: `/api`, BPrefix: true, Expected: pathmatch.CoveredBy},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.A+` `+tc.B, func(t *testing.T) {
+			a, err := pathmatch.Parse(tc.A)
+			require.NoError(t, err, `path.Parse should succeed`)
+			b, err := pathmatch.Parse(tc.B)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			require.Equal(t, tc.Expected, pathmatch.Compare(a, tc.APrefix, b, tc.BPrefix), `relation should match`)
+		})
+	}
+
+	t.Run("fold case", func(t *testing.T) {
+		testcases := []struct {
+			A        string
+			AFold    bool
+			B        string
+			BFold    bool
+			Expected pathmatch.Relation
+		}{
+			{A: `/users`, AFold: true, B: `/Users`, Expected: pathmatch.Covers},
+			{A: `/users`, AFold: true, B: `/USERS`, BFold: true, Expected: pathmatch.Equivalent},
+			{A: `/users`, B: `/USERS`, BFold: true, Expected: pathmatch.CoveredBy},
+			{A: `/users`, AFold: true, B: `/posts`, Expected: pathmatch.Disjoint},
+			{A: `/users/{id}`, B: `/USERS/me`, BFold: true, Expected: pathmatch.Overlaps},
+			{A: `/users/{id:[a-z]+}`, AFold: true, B: `/users/me`, BFold: true, Expected: pathmatch.Overlaps},
+			{A: `/123`, B: `/123`, BFold: true, Expected: pathmatch.Equivalent},
+		}
+		for _, tc := range testcases {
+			a, err := pathmatch.Parse(tc.A)
+			require.NoError(t, err, `path.Parse should succeed`)
+			if tc.AFold {
+				a.FoldCase()
+			}
+			b, err := pathmatch.Parse(tc.B)
+			require.NoError(t, err, `path.Parse should succeed`)
+			if tc.BFold {
+				b.FoldCase()
+			}
+
+			require.Equal(t, tc.Expected, pathmatch.Compare(a, false, b, false), `relation between %q and %q should match`, tc.A, tc.B)
+		}
+	})
+}
+
+func TestCompareSpecificity(t *testing.T) {
+	testcases := []struct {
+		A        string
+		APrefix  bool
+		B        string
+		BPrefix  bool
+		Expected int
+	}{
+		{A: `/users/me`, B: `/users/{id}`, Expected: -1},
+		{A: `/users/{id:int}`, B: `/users/{id}`, Expected: -1},
+		{A: `/users/{id:^[0-9]+$}`, B: `/users/{id}`, Expected: -1},
+		{A: `/users/{id:int}`, B: `/users/{id:^[0-9]+$}`, Expected: 0},
+		{A: `/users/{id}`, B: `/users/{path...}`, Expected: -1},
+		{A: `/files/{name}.json`, B: `/files/{id:int}`, Expected: -1},
+		{A: `/users/{id}/posts`, B: `/users/me/{tab}`, Expected: 1},
+		{A: `/posts`, B: `/posts[/{page}]`, Expected: -1},
+		{A: `/api/users`, B: `/api`, BPrefix: true, Expected: -1},
+		{A: `/api/{path...}`, B: `/api`, BPrefix: true, Expected: 0},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.A+` `+tc.B, func(t *testing.T) {
+			a, err := pathmatch.Parse(tc.A)
+			require.NoError(t, err, `path.Parse should succeed`)
+			b, err := pathmatch.Parse(tc.B)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			got := pathmatch.CompareSpecificity(a, tc.APrefix, b, tc.BPrefix)
+			switch {
+			case tc.Expected < 0:
+				require.Negative(t, got, `a should be more specific`)
+			case tc.Expected > 0:
+				require.Positive(t, got, `b should be more specific`)
+			default:
+				require.Zero(t, got, `a and b should be equally specific`)
+			}
+			require.Equal(t, -got, pathmatch.CompareSpecificity(b, tc.BPrefix, a, tc.APrefix), `comparison should be symmetric`)
+		})
+	}
+}
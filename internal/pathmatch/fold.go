@@ -0,0 +1,95 @@
+package pathmatch
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldLiteralConsumer matches a literal component regardless of case,
+// using Unicode simple case folding. The literal is stored in its folded
+// form, as returned by foldString
+type foldLiteralConsumer string
+
+func (c foldLiteralConsumer) Consume(s string, _ Values) (string, error) {
+	n, ok := hasPrefixFold(s, string(c))
+	if !ok {
+		return s, fmt.Errorf(`failed to match literal pattern %q`, c)
+	}
+	return s[n:], nil
+}
+
+// FoldCase makes the literal components of the pattern match the input
+// regardless of case. Variables are not affected, and capture the input
+// as it is. FoldCase must be called before the Matcher is used
+func (p *Matcher) FoldCase() {
+	for _, v := range p.variants {
+		foldLiterals(v.consumers)
+	}
+}
+
+func foldLiterals(consumers []consumer) {
+	for i, c := range consumers {
+		switch c := c.(type) {
+		case literalConsumer:
+			consumers[i] = foldLiteralConsumer(foldString(string(c)))
+		case *compoundConsumer:
+			foldLiterals(c.parts)
+		}
+	}
+}
+
+// foldRune maps every rune to a representative of the runes that are
+// equivalent to it under simple case folding, so that two strings are
+// equal regardless of case if and only if their folded forms are equal.
+// The representative is the lower case form of the smallest equivalent
+// rune, which keeps folded strings readable
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return r
+	}
+
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return unicode.ToLower(min)
+}
+
+func foldString(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// hasPrefixFold returns true if `s` starts with the folded string
+// `prefix` regardless of case, along with the number of bytes in `s`
+// that the prefix corresponds to
+func hasPrefixFold(s, prefix string) (int, bool) {
+	var n int
+	for _, pr := range prefix {
+		if n >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if foldRune(r) != pr {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// caseless returns true if none of the runes in `s` have other cases
+func caseless(s string) bool {
+	for _, r := range s {
+		if unicode.SimpleFold(r) != r {
+			return false
+		}
+	}
+	return true
+}
@@ -20,7 +20,25 @@ func (v Values) Get(s string) string {
 }
 
 type Matcher struct {
+	exprs []Expression
+
+	// variants contains the sequences of consumers for every combination
+	// of optional sections being present or absent, in order of preference
+	variants []*variant
+
+	// consumers maps variable expressions to their compiled consumers
+	consumers map[Expression]consumer
+
+	// sep is the byte that separates segments
+	sep byte
+}
+
+type variant struct {
 	consumers []consumer
+
+	// defaults contains the default values of the variables in the
+	// optional sections that are absent from this variant
+	defaults map[string]string
 }
 
 type consumer interface {
@@ -39,13 +57,14 @@ func (c literalConsumer) Consume(s string, _ Values) (string, error) {
 
 type segmentConsumer struct {
 	name string
+	sep  byte
 }
 
 func (c *segmentConsumer) Consume(s string, mv Values) (string, error) {
 	var val string
 	// ([^/]+)/...
 	// (lastsegment)
-	i := strings.IndexByte(s, '/')
+	i := strings.IndexByte(s, c.sep)
 	if i == -1 {
 		// it's not an error if we still have something left
 		if len(s) > 0 {
@@ -63,9 +82,35 @@ func (c *segmentConsumer) Consume(s string, mv Values) (string, error) {
 	return s, nil
 }
 
+// typedConsumer matches exactly one segment, whose value must
+// satisfy the type
+type typedConsumer struct {
+	name string
+	typ  string
+	fn   TypeFunc
+	sep  byte
+}
+
+func (c *typedConsumer) Consume(s string, mv Values) (string, error) {
+	i := strings.IndexByte(s, c.sep)
+	if i == -1 {
+		i = len(s)
+	}
+	if !c.fn(s[:i]) {
+		return s, fmt.Errorf(`failed to match %q as %s`, c.name, c.typ)
+	}
+	mv[c.name] = s[:i]
+	return s[i:], nil
+}
+
 type regexpConsumer struct {
 	name    string
 	pattern *regexp.Regexp
+
+	// value is the pattern anchored at both ends, which is used to
+	// match values on their own
+	value *regexp.Regexp
+	sep   byte
 }
 
 func (c *regexpConsumer) Consume(s string, mv Values) (string, error) {
@@ -81,7 +126,7 @@ func (c *regexpConsumer) Consume(s string, mv Values) (string, error) {
 
 	var val string
 	// Find next '/'
-	i := strings.IndexByte(s[loc[1]:], '/')
+	i := strings.IndexByte(s[loc[1]:], c.sep)
 	if i == -1 { // read up to EOF
 		val = s
 		s = ""
@@ -93,57 +138,353 @@ func (c *regexpConsumer) Consume(s string, mv Values) (string, error) {
 	return s, nil
 }
 
+// wildcardConsumer captures the remainder of the input, which may be empty
+type wildcardConsumer struct {
+	name string
+}
+
+func (c *wildcardConsumer) Consume(s string, mv Values) (string, error) {
+	mv[c.name] = s
+	return "", nil
+}
+
+// Parse parses a path pattern, where variable components of the form
+// `{name}` match a single path segment delimited by slashes (`/`)
 func Parse(s string) (*Matcher, error) {
+	return parseMatcher(s, '/')
+}
+
+// ParseHost parses a host pattern, where variable components of the form
+// `{name}` match a single label delimited by dots (`.`). Literal parts of
+// the pattern are converted to lower case, and therefore the input to
+// `Match` should be in lower case as well
+func ParseHost(s string) (*Matcher, error) {
+	m, err := parseMatcher(s, '.')
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range m.variants {
+		lowerLiterals(v.consumers)
+	}
+	return m, nil
+}
+
+func lowerLiterals(consumers []consumer) {
+	for i, c := range consumers {
+		switch c := c.(type) {
+		case literalConsumer:
+			consumers[i] = literalConsumer(strings.ToLower(string(c)))
+		case *compoundConsumer:
+			lowerLiterals(c.parts)
+		}
+	}
+}
+
+func parseMatcher(s string, sep byte) (*Matcher, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	exprs, err := parse(ctx, s)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse path pattern: %w`, err)
 	}
+	if err := checkWildcards(exprs, true); err != nil {
+		return nil, err
+	}
+	exprs = desugarOptionals(exprs)
+	if n := countVariants(exprs); n > maxVariants {
+		return nil, fmt.Errorf(`failed to parse path pattern: more than %d combinations of optional sections`, maxVariants)
+	}
+
+	m := &Matcher{
+		exprs:     exprs,
+		consumers: make(map[Expression]consumer),
+		sep:       sep,
+	}
+	if err := m.compile(exprs, sep); err != nil {
+		return nil, err
+	}
 
-	consumers := make([]consumer, 0, len(exprs))
+	for _, exprs := range expand(exprs) {
+		consumers, err := m.segmentConsumers(mergeLiterals(exprs.exprs), sep)
+		if err != nil {
+			return nil, err
+		}
+		m.variants = append(m.variants, &variant{
+			consumers: consumers,
+			defaults:  exprs.defaults,
+		})
+	}
+	return m, nil
+}
+
+// compile creates the consumers for all variable components
+func (p *Matcher) compile(exprs []Expression, sep byte) error {
 	for _, expr := range exprs {
 		switch expr := expr.(type) {
 		case *Literal:
-			consumers = append(consumers, literalConsumer(expr.Lit))
+		case *Optional:
+			if err := p.compile(expr.Exprs, sep); err != nil {
+				return err
+			}
 		case *LiteralPattern:
-			consumers = append(consumers, &segmentConsumer{
+			p.consumers[expr] = &segmentConsumer{
+				name: expr.Name,
+				sep:  sep,
+			}
+		case *TypedPattern:
+			fn, ok := lookupType(expr.Type)
+			if !ok {
+				return fmt.Errorf(`unknown type %q for %q`, expr.Type, expr.Name)
+			}
+			p.consumers[expr] = &typedConsumer{
 				name: expr.Name,
-			})
+				typ:  expr.Type,
+				fn:   fn,
+				sep:  sep,
+			}
+		case *Wildcard:
+			p.consumers[expr] = &wildcardConsumer{
+				name: expr.Name,
+			}
 		case *RegexpPattern:
 			pat, err := regexp.Compile(expr.Pattern)
 			if err != nil {
-				return nil, fmt.Errorf(`failed to compile pattern for %q: %w`, expr.Name, err)
+				return fmt.Errorf(`failed to compile pattern for %q: %w`, expr.Name, err)
 			}
-			consumers = append(consumers, &regexpConsumer{
+			value, err := regexp.Compile(`^(?:` + expr.Pattern + `)$`)
+			if err != nil {
+				return fmt.Errorf(`failed to compile pattern for %q: %w`, expr.Name, err)
+			}
+			p.consumers[expr] = &regexpConsumer{
 				name:    expr.Name,
 				pattern: pat,
-			})
+				value:   value,
+				sep:     sep,
+			}
 		default:
-			return nil, fmt.Errorf(`invalid expression %T`, expr)
+			return fmt.Errorf(`invalid expression %T`, expr)
+		}
+
+		// default values must be acceptable as values of the variable
+		if v, ok := expr.(variableExpression); ok && v.variable().HasDefault {
+			if c, ok := p.consumers[expr].(variableConsumer); ok && !c.matchValue(v.variable().Default) {
+				return fmt.Errorf(`invalid default value %q for %q`, v.variable().Default, v.variable().Name)
+			}
 		}
 	}
-	return &Matcher{
-		consumers: consumers,
-	}, nil
+	return nil
+}
+
+// checkWildcards makes sure that wildcards only appear at the end of
+// the pattern. A wildcard may be the last component of an optional
+// section, as long as the section itself is at the end of the pattern
+func checkWildcards(exprs []Expression, last bool) error {
+	for i, expr := range exprs {
+		isLast := last && i == len(exprs)-1
+		switch expr := expr.(type) {
+		case *Optional:
+			if err := checkWildcards(expr.Exprs, isLast); err != nil {
+				return err
+			}
+		case *Wildcard:
+			if !isLast {
+				return fmt.Errorf(`wildcard %q must be at the end of the pattern`, expr.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// desugarOptionals rewrites variables declared as `{name?}` or
+// `{name=default}` into optional sections. If the variable is preceded
+// by a slash, the slash becomes part of the optional section, so that
+// `/posts/{page?}` is equivalent to `/posts[/{page}]`
+func desugarOptionals(exprs []Expression) []Expression {
+	result := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case *Optional:
+			e.Exprs = desugarOptionals(e.Exprs)
+		case variableExpression:
+			if !e.variable().Optional {
+				break
+			}
+
+			section := []Expression{expr}
+			if n := len(result); n > 0 {
+				if lit, ok := result[n-1].(*Literal); ok && strings.HasSuffix(lit.Lit, `/`) {
+					if trimmed := strings.TrimSuffix(lit.Lit, `/`); trimmed == "" {
+						result = result[:n-1]
+					} else {
+						result[n-1] = NewLiteral(trimmed)
+					}
+					section = []Expression{NewLiteral(`/`), expr}
+				}
+			}
+			expr = NewOptional(section)
+		}
+		result = append(result, expr)
+	}
+	return result
+}
+
+// maxVariants is the maximum number of combinations of optional sections
+// in a pattern. Each combination is matched separately, and their number
+// doubles with every optional section that is not nested in another one
+const maxVariants = 64
+
+// countVariants returns the number of combinations of optional sections
+// that `expand` would return, or maxVariants+1 if there are more than
+// maxVariants
+func countVariants(exprs []Expression) int {
+	n := 1
+	for _, expr := range exprs {
+		opt, ok := expr.(*Optional)
+		if !ok {
+			continue
+		}
+		n *= countVariants(opt.Exprs) + 1
+		if n > maxVariants {
+			return maxVariants + 1
+		}
+	}
+	return n
+}
+
+type expansion struct {
+	exprs    []Expression
+	defaults map[string]string
+}
+
+// expand returns the flattened list of expressions for every combination
+// of optional sections being present or absent. Combinations where the
+// sections are present come first
+func expand(exprs []Expression) []expansion {
+	result := []expansion{{}}
+	for _, expr := range exprs {
+		opt, ok := expr.(*Optional)
+		if !ok {
+			for i := range result {
+				result[i].exprs = append(result[i].exprs[:len(result[i].exprs):len(result[i].exprs)], expr)
+			}
+			continue
+		}
+
+		var next []expansion
+		for _, prefix := range result {
+			for _, sub := range expand(opt.Exprs) {
+				next = append(next, expansion{
+					exprs:    concatExprs(prefix.exprs, sub.exprs),
+					defaults: mergeDefaults(prefix.defaults, sub.defaults),
+				})
+			}
+
+			absent := expansion{
+				exprs:    prefix.exprs,
+				defaults: prefix.defaults,
+			}
+			for _, v := range variables(opt.Exprs) {
+				if v.HasDefault {
+					absent.defaults = mergeDefaults(absent.defaults, map[string]string{v.Name: v.Default})
+				}
+			}
+			next = append(next, absent)
+		}
+		result = next
+	}
+	return result
+}
+
+func concatExprs(a, b []Expression) []Expression {
+	result := make([]Expression, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+func mergeDefaults(a, b map[string]string) map[string]string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	result := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		result[k] = v
+	}
+	for k, v := range b {
+		result[k] = v
+	}
+	return result
+}
+
+// variables returns all variables in the expressions, including those
+// in optional sections
+func variables(exprs []Expression) []*Variable {
+	var result []*Variable
+	for _, expr := range exprs {
+		switch expr := expr.(type) {
+		case *Optional:
+			result = append(result, variables(expr.Exprs)...)
+		case variableExpression:
+			result = append(result, expr.variable())
+		}
+	}
+	return result
 }
 
 func (p *Matcher) Match(s string) (Values, error) {
+	for _, v := range p.variants {
+		mv, rest, err := v.consume(s)
+		if err != nil || rest != "" {
+			continue
+		}
+		return mv, nil
+	}
+	return nil, fmt.Errorf(`failed to match input`)
+}
+
+// MatchPrefix matches the beginning of the input against the pattern.
+// The match must end at a path boundary: either at the end of the input,
+// or right before or after a slash (`/`). The unprocessed portion of the
+// input is returned
+func (p *Matcher) MatchPrefix(s string) (Values, string, error) {
+	for _, v := range p.variants {
+		mv, rest, err := v.consume(s)
+		if err != nil || !atBoundary(s, rest) {
+			continue
+		}
+		return mv, rest, nil
+	}
+	return nil, "", fmt.Errorf(`failed to match input`)
+}
+
+func (v *variant) consume(s string) (Values, string, error) {
 	mv := make(Values)
-	for _, c := range p.consumers {
+	for _, c := range v.consumers {
 		ps, err := c.Consume(s, mv)
 		if err != nil {
-			return nil, fmt.Errorf(`failed to match input: %w`, err)
+			return nil, "", fmt.Errorf(`failed to match input: %w`, err)
 		}
 		s = ps
-		if s == "" {
-			break
-		}
 	}
-	// we can't have anything unprocessed
-	if s != "" {
-		return nil, fmt.Errorf(`failed to match input (trailing input)`)
+	for name, value := range v.defaults {
+		mv[name] = value
 	}
+	return mv, s, nil
+}
 
-	return mv, nil
+// Expressions returns the parsed components of the pattern
+func (p *Matcher) Expressions() []Expression {
+	return p.exprs
+}
+
+// Variables returns the names of the variable components of the pattern,
+// including those in optional sections
+func (p *Matcher) Variables() []string {
+	var names []string
+	for _, v := range variables(p.exprs) {
+		names = append(names, v.Name)
+	}
+	return names
 }
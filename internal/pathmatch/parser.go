@@ -24,12 +24,19 @@ type yySymType struct {
 	yys   int
 	token *yyToken
 	expr  Expression
+	exprs []Expression
 }
 
 const tLiteral = 57346
 const tOpenBrace = 57347
 const tCloseBrace = 57348
 const tColon = 57349
+const tTypeName = 57350
+const tOpenBracket = 57351
+const tCloseBracket = 57352
+const tQuestion = 57353
+const tEquals = 57354
+const tEllipsis = 57355
 
 var yyToknames = [...]string{
 	"$end",
@@ -39,6 +46,12 @@ var yyToknames = [...]string{
 	"tOpenBrace",
 	"tCloseBrace",
 	"tColon",
+	"tTypeName",
+	"tOpenBracket",
+	"tCloseBracket",
+	"tQuestion",
+	"tEquals",
+	"tEllipsis",
 }
 
 var yyStatenames = [...]string{}
@@ -55,38 +68,44 @@ var yyExca = [...]int8{
 
 const yyPrivate = 57344
 
-const yyLast = 11
+const yyLast = 24
 
 var yyAct = [...]int8{
-	10, 9, 5, 4, 11, 8, 2, 3, 7, 1,
-	6,
+	15, 3, 6, 4, 7, 19, 16, 5, 17, 20,
+	13, 14, 12, 7, 6, 4, 2, 18, 10, 5,
+	9, 8, 11, 1,
 }
 
 var yyPact = [...]int16{
-	-2, -1000, -1000, -2, 1, -1000, -1000, -5, -7, -1000,
-	0, -1000,
+	10, -32768, 10, -32768, 14, 10, -32768, -32768, 6, -1,
+	-7, -2, -32768, -32768, 13, 1, -32768, -32768, -32768, -32768,
+	-32768,
 }
 
 var yyPgo = [...]int8{
-	0, 9, 8, 6, 7,
+	0, 23, 16, 1, 21, 20,
 }
 
 var yyR1 = [...]int8{
-	0, 1, 3, 3, 4, 4, 2, 2,
+	0, 1, 2, 2, 3, 3, 3, 4, 4, 4,
+	5, 5, 5, 5,
 }
 
 var yyR2 = [...]int8{
-	0, 1, 1, 2, 3, 1, 3, 1,
+	0, 1, 1, 2, 3, 3, 1, 1, 2, 3,
+	3, 3, 2, 1,
 }
 
 var yyChk = [...]int16{
-	-1000, -1, -3, -4, 5, 4, -3, -2, 4, 6,
-	7, 4,
+	-32768, -1, -2, -3, 5, 9, 4, -3, -4, -5,
+	4, -2, 6, 11, 12, 7, 13, 10, 4, 4,
+	8,
 }
 
 var yyDef = [...]int8{
-	0, -2, 1, 2, 0, 5, 3, 0, 7, 4,
-	0, 6,
+	0, -2, 1, 2, 0, 0, 6, 3, 0, 7,
+	13, 0, 4, 8, 0, 0, 12, 5, 9, 10,
+	11,
 }
 
 var yyTok1 = [...]int8{
@@ -94,7 +113,8 @@ var yyTok1 = [...]int8{
 }
 
 var yyTok2 = [...]int8{
-	2, 3, 4, 5, 6, 7,
+	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
+	12, 13,
 }
 
 var yyTok3 = [...]int8{
@@ -138,7 +158,7 @@ func yyNewParser() yyParser {
 	return &yyParserImpl{}
 }
 
-const yyFlag = -1000
+const yyFlag = -32768
 
 func yyTokname(c int) string {
 	if c >= 1 && c-1 < len(yyToknames) {
@@ -436,21 +456,23 @@ yydefault:
 	// dummy call; replaced with literal code
 	switch yynt {
 
-	case 2:
+	case 1:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.expr = yyDollar[1].expr
+			yyVAL.exprs = yyDollar[1].exprs
 			if l, ok := yylex.(*lexer); ok {
-				l.exprs = append([]Expression{yyVAL.expr}, l.exprs...)
+				l.exprs = yyVAL.exprs
 			}
 		}
+	case 2:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.exprs = []Expression{yyDollar[1].expr}
+		}
 	case 3:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.expr = yyDollar[1].expr
-			if l, ok := yylex.(*lexer); ok {
-				l.exprs = append([]Expression{yyVAL.expr}, l.exprs...)
-			}
+			yyVAL.exprs = append(yyDollar[1].exprs, yyDollar[2].expr)
 		}
 	case 4:
 		yyDollar = yyS[yypt-3 : yypt+1]
@@ -458,16 +480,46 @@ yydefault:
 			yyVAL.expr = yyDollar[2].expr
 		}
 	case 5:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.expr = NewOptional(yyDollar[2].exprs)
+		}
+	case 6:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.expr = NewLiteral(yyDollar[1].token.lit.(string))
 		}
-	case 6:
+	case 7:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.expr = yyDollar[1].expr
+		}
+	case 8:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.expr = markOptional(yyDollar[1].expr)
+		}
+	case 9:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.expr = markDefault(yyDollar[1].expr, yyDollar[3].token.lit.(string))
+		}
+	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.expr = NewRegexpPattern(yyDollar[1].token.lit.(string), yyDollar[3].token.lit.(string))
 		}
-	case 7:
+	case 11:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.expr = NewTypedPattern(yyDollar[1].token.lit.(string), yyDollar[3].token.lit.(string))
+		}
+	case 12:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.expr = NewWildcard(yyDollar[1].token.lit.(string))
+		}
+	case 13:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.expr = NewLiteralPattern(yyDollar[1].token.lit.(string))
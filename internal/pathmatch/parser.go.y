@@ -1,8 +1,10 @@
 // Syntax:
 //
 // path: expr ...
-// expr: literal | pattern
-// pattern: patname (colon pattern)
+// expr: literal | pattern | optional
+// pattern: variable | variable question | variable equals default
+// variable: patname (colon (typename | regexp)) | patname ellipsis
+// optional: open_bracket expr ... close_bracket
 
 %{
 package pathmatch
@@ -29,33 +31,35 @@ func parse(ctx context.Context, s string) ([]Expression, error) {
 %union{
 	token *yyToken
 	expr  Expression
+	exprs []Expression
 }
 
-%type<expr> path
-%type<expr> pattern
-%type<expr> exprs
+%type<exprs> path
+%type<exprs> exprs
 %type<expr> expr
-%token<token> tLiteral tOpenBrace tCloseBrace tColon
+%type<expr> pattern
+%type<expr> variable
+%token<token> tLiteral tOpenBrace tCloseBrace tColon tTypeName tOpenBracket tCloseBracket tQuestion tEquals tEllipsis
 
 %%
 
 path
 	: exprs
-
-exprs
-	: expr
 	{
 		$$ = $1
 		if l, ok := yylex.(*lexer); ok {
-			l.exprs = append([]Expression{$$}, l.exprs...)
+			l.exprs = $$
 		}
 	}
-	| expr exprs
+
+exprs
+	: expr
+	{
+		$$ = []Expression{$1}
+	}
+	| exprs expr
 	{
-		$$ = $1
-		if l, ok := yylex.(*lexer); ok {
-			l.exprs = append([]Expression{$$}, l.exprs...)
-		}
+		$$ = append($1, $2)
 	}
 
 expr
@@ -63,16 +67,42 @@ expr
 	{
 		$$ = $2
 	}
+	| tOpenBracket exprs tCloseBracket
+	{
+		$$ = NewOptional($2)
+	}
 	| tLiteral
 	{
 		$$ = NewLiteral($1.lit.(string))
 	}
 
 pattern
+	: variable
+	{
+		$$ = $1
+	}
+	| variable tQuestion
+	{
+		$$ = markOptional($1)
+	}
+	| variable tEquals tLiteral
+	{
+		$$ = markDefault($1, $3.lit.(string))
+	}
+
+variable
 	: tLiteral tColon tLiteral
 	{
 		$$ = NewRegexpPattern($1.lit.(string), $3.lit.(string))
 	}
+	| tLiteral tColon tTypeName
+	{
+		$$ = NewTypedPattern($1.lit.(string), $3.lit.(string))
+	}
+	| tLiteral tEllipsis
+	{
+		$$ = NewWildcard($1.lit.(string))
+	}
 	| tLiteral
 	{
 		$$ = NewLiteralPattern($1.lit.(string))
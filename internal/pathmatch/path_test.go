@@ -1,7 +1,10 @@
 package pathmatch_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/lestrrat-go/mux/internal/pathmatch"
 	"github.com/stretchr/testify/require"
@@ -33,3 +36,350 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchPrefix(t *testing.T) {
+	testcases := []struct {
+		Pattern string
+		Input   string
+		Rest    string
+		Error   bool
+	}{
+		{Pattern: `/api/{version}`, Input: `/api/v1`, Rest: ``},
+		{Pattern: `/api/{version}`, Input: `/api/v1/users`, Rest: `/users`},
+		{Pattern: `/api/`, Input: `/api/users`, Rest: `users`},
+		{Pattern: `/api`, Input: `/apis`, Error: true},
+		{Pattern: `/api`, Input: `/ap`, Error: true},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			_, rest, err := p.MatchPrefix(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.MatchPrefix should fail`)
+				return
+			}
+			require.NoError(t, err, `p.MatchPrefix should succeed`)
+			require.Equal(t, tc.Rest, rest, `rest should match`)
+		})
+	}
+}
+
+func TestTypedPattern(t *testing.T) {
+	require.NoError(t, pathmatch.RegisterType(`even`, func(s string) bool {
+		return len(s) > 0 && (s[len(s)-1]-'0')%2 == 0
+	}), `pathmatch.RegisterType should succeed`)
+	require.Error(t, pathmatch.RegisterType(`even`, func(string) bool { return true }), `registering a duplicate type should fail`)
+	require.Error(t, pathmatch.RegisterType(`not-an-ident`, func(string) bool { return true }), `registering an invalid name should fail`)
+
+	testcases := []struct {
+		Pattern string
+		Input   string
+		Value   string
+		Error   bool
+	}{
+		{Pattern: `/items/{id:int}`, Input: `/items/-123`, Value: `-123`},
+		{Pattern: `/items/{id:int}`, Input: `/items/123abc`, Error: true},
+		{Pattern: `/items/{id:int}/view`, Input: `/items/123/view`, Value: `123`},
+		{Pattern: `/items/{id:uint}`, Input: `/items/-123`, Error: true},
+		{Pattern: `/items/{id:uuid}`, Input: `/items/6ba7b810-9dad-11d1-80b4-00c04fd430c8`, Value: `6ba7b810-9dad-11d1-80b4-00c04fd430c8`},
+		{Pattern: `/items/{id:uuid}`, Input: `/items/6ba7b810-9dad-11d1-80b4`, Error: true},
+		{Pattern: `/items/{id:alpha}`, Input: `/items/abcXYZ`, Value: `abcXYZ`},
+		{Pattern: `/items/{id:alpha}`, Input: `/items/abc1`, Error: true},
+		{Pattern: `/items/{id:alnum}`, Input: `/items/abc1`, Value: `abc1`},
+		{Pattern: `/items/{id:hex}`, Input: `/items/deadBEEF`, Value: `deadBEEF`},
+		{Pattern: `/items/{id:hex}`, Input: `/items/xyz`, Error: true},
+		{Pattern: `/items/{id:date}`, Input: `/items/2022-02-28`, Value: `2022-02-28`},
+		{Pattern: `/items/{id:date}`, Input: `/items/2022-02-30`, Error: true},
+		{Pattern: `/items/{id:even}`, Input: `/items/12`, Value: `12`},
+		{Pattern: `/items/{id:even}`, Input: `/items/13`, Error: true},
+		{Pattern: `/items/{id:int}`, Input: `/items/123/456`, Error: true},
+		// an expression that is not an identifier is still a regexp
+		{Pattern: `/items/{id:^[0-9]+}`, Input: `/items/123abc`, Value: `123abc`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			mv, err := p.Match(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.Match should fail`)
+				return
+			}
+			require.NoError(t, err, `p.Match should succeed`)
+			require.Equal(t, tc.Value, mv.Get(`id`), `value should match`)
+		})
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := pathmatch.Parse(`/items/{id:nosuchtype}`)
+		require.Error(t, err, `path.Parse should fail`)
+	})
+}
+
+func TestOptional(t *testing.T) {
+	testcases := []struct {
+		Pattern  string
+		Input    string
+		Expected pathmatch.Values
+		Error    bool
+	}{
+		{Pattern: `/posts[/{page}]`, Input: `/posts`, Expected: pathmatch.Values{}},
+		{Pattern: `/posts[/{page}]`, Input: `/posts/2`, Expected: pathmatch.Values{`page`: `2`}},
+		{Pattern: `/posts[/{page}]`, Input: `/posts/`, Error: true},
+		{Pattern: `/posts/{page?}`, Input: `/posts`, Expected: pathmatch.Values{}},
+		{Pattern: `/posts/{page?}`, Input: `/posts/2`, Expected: pathmatch.Values{`page`: `2`}},
+		{Pattern: `/posts/{page:int?}`, Input: `/posts/abc`, Error: true},
+		{Pattern: `/posts/{page:int?}`, Input: `/posts/10`, Expected: pathmatch.Values{`page`: `10`}},
+		{Pattern: `/report[.{format=json}]`, Input: `/report`, Expected: pathmatch.Values{`format`: `json`}},
+		{Pattern: `/report/{format=json}`, Input: `/report/csv`, Expected: pathmatch.Values{`format`: `csv`}},
+		{Pattern: `/report.{format=json}`, Input: `/report.xml`, Expected: pathmatch.Values{`format`: `xml`}},
+		{Pattern: `/report[.{format=json}]`, Input: `/report`, Expected: pathmatch.Values{`format`: `json`}},
+		{Pattern: `/archive[/{year:int}[/{month:int}]]`, Input: `/archive/2020/12`, Expected: pathmatch.Values{`year`: `2020`, `month`: `12`}},
+		{Pattern: `/archive[/{year:int}[/{month:int}]]`, Input: `/archive/2020`, Expected: pathmatch.Values{`year`: `2020`}},
+		{Pattern: `/archive[/{year:int}[/{month:int}]]`, Input: `/archive`, Expected: pathmatch.Values{}},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			mv, err := p.Match(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.Match should fail`)
+				return
+			}
+			require.NoError(t, err, `p.Match should succeed`)
+			require.Equal(t, tc.Expected, mv, `values should match`)
+		})
+	}
+
+	t.Run("invalid syntax", func(t *testing.T) {
+		for _, pattern := range []string{`/posts[/{page}`, `/posts/{page}]`, `/posts/{page?x}`} {
+			_, err := pathmatch.Parse(pattern)
+			require.Error(t, err, `path.Parse(%q) should fail`, pattern)
+		}
+	})
+	t.Run("invalid default values", func(t *testing.T) {
+		testcases := []struct {
+			Pattern string
+			Error   bool
+		}{
+			{Pattern: `/p/{page:int=abc}`, Error: true},
+			{Pattern: `/p/{page:int=-1}`},
+			{Pattern: `/p/{page:uint=-1}`, Error: true},
+			{Pattern: `/p/{x=a/b}`, Error: true},
+			{Pattern: `/p/{x=}`, Error: true},
+			{Pattern: `/p/{x=a}`},
+			{Pattern: `/p/{x:alpha=abc}`},
+			{Pattern: `/p/{x:alpha=a1}`, Error: true},
+			{Pattern: `/p[/{x:int=a/b}]`, Error: true},
+			{Pattern: `/p/{name=a}.{ext=a.b}`},
+			{Pattern: `/p/{name=a}.{ext=a/b}`, Error: true},
+		}
+		for _, tc := range testcases {
+			_, err := pathmatch.Parse(tc.Pattern)
+			if tc.Error {
+				require.Error(t, err, `path.Parse(%q) should fail`, tc.Pattern)
+				continue
+			}
+			require.NoError(t, err, `path.Parse(%q) should succeed`, tc.Pattern)
+		}
+	})
+	t.Run("too many optional sections", func(t *testing.T) {
+		repeat := func(n int, format string) string {
+			var b strings.Builder
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(&b, format, i)
+			}
+			return b.String()
+		}
+
+		_, err := pathmatch.Parse(repeat(6, `[/{a%d}]`))
+		require.NoError(t, err, `path.Parse should accept 64 combinations of optional sections`)
+
+		for _, pattern := range []string{
+			repeat(7, `[/{a%d}]`),
+			repeat(64, `/{a%d?}`),
+			repeat(4, `[/{a%[1]d}[/{b%[1]d}]]`),
+		} {
+			_, err := pathmatch.Parse(pattern)
+			require.Error(t, err, `path.Parse(%q) should fail`, pattern)
+		}
+
+		// nested sections only add one combination each
+		_, err = pathmatch.Parse(repeat(32, `[/{a%d}`) + strings.Repeat(`]`, 32))
+		require.NoError(t, err, `path.Parse should accept nested optional sections`)
+	})
+}
+
+func TestWildcard(t *testing.T) {
+	testcases := []struct {
+		Pattern string
+		Input   string
+		Value   string
+		Error   bool
+	}{
+		{Pattern: `/files/{path...}`, Input: `/files/css/main.css`, Value: `css/main.css`},
+		{Pattern: `/files/{path...}`, Input: `/files/`, Value: ``},
+		{Pattern: `/files/{path...}`, Input: `/files`, Error: true},
+		{Pattern: `/files[/{path...}]`, Input: `/files`, Value: ``},
+		{Pattern: `/files[/{path...}]`, Input: `/files/a/b/`, Value: `a/b/`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			mv, err := p.Match(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.Match should fail`)
+				return
+			}
+			require.NoError(t, err, `p.Match should succeed`)
+			require.Equal(t, tc.Value, mv.Get(`path`), `value should match`)
+		})
+	}
+
+	t.Run("invalid syntax", func(t *testing.T) {
+		for _, pattern := range []string{`/files/{path...}/view`, `/files[/{path...}]/view`, `/files/{path..}`, `/files/{path....}`} {
+			_, err := pathmatch.Parse(pattern)
+			require.Error(t, err, `path.Parse(%q) should fail`, pattern)
+		}
+	})
+}
+
+func TestCompoundSegment(t *testing.T) {
+	testcases := []struct {
+		Pattern  string
+		Input    string
+		Expected pathmatch.Values
+		Error    bool
+	}{
+		{Pattern: `/files/{name}.{ext}`, Input: `/files/report.pdf`, Expected: pathmatch.Values{`name`: `report`, `ext`: `pdf`}},
+		{Pattern: `/files/{name}.{ext}`, Input: `/files/archive.tar.gz`, Expected: pathmatch.Values{`name`: `archive`, `ext`: `tar.gz`}},
+		{Pattern: `/files/{name}.{ext}`, Input: `/files/report`, Error: true},
+		{Pattern: `/files/{name}.{ext}`, Input: `/files/report.`, Error: true},
+		{Pattern: `/files/{name}.tar.gz`, Input: `/files/a.b.tar.gz`, Expected: pathmatch.Values{`name`: `a.b`}},
+		{Pattern: `/v{major:int}.{minor:int}/users`, Input: `/v1.12/users`, Expected: pathmatch.Values{`major`: `1`, `minor`: `12`}},
+		{Pattern: `/v{major:int}.{minor:int}/users`, Input: `/v1.x/users`, Error: true},
+		{Pattern: `/{from:[0-9]+}-{to:[0-9]+}`, Input: `/10-20`, Expected: pathmatch.Values{`from`: `10`, `to`: `20`}},
+		{Pattern: `/{from:[0-9]+}-{to:[0-9]+}`, Input: `/10-20a`, Error: true},
+		{Pattern: `/{id}.json/view`, Input: `/abc.json/view`, Expected: pathmatch.Values{`id`: `abc`}},
+		{Pattern: `/{id}.json/view`, Input: `/abc/x.json/view`, Error: true},
+		{Pattern: `/{name}[.{format}]`, Input: `/report`, Expected: pathmatch.Values{`name`: `report`}},
+		{Pattern: `/{name}[.{format}]`, Input: `/report.csv`, Expected: pathmatch.Values{`name`: `report`, `format`: `csv`}},
+		{Pattern: `/{user}@{rest...}`, Input: `/john@a/b`, Expected: pathmatch.Values{`user`: `john`, `rest`: `a/b`}},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			mv, err := p.Match(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.Match should fail`)
+				return
+			}
+			require.NoError(t, err, `p.Match should succeed`)
+			require.Equal(t, tc.Expected, mv, `values should match`)
+		})
+	}
+
+	t.Run("ambiguous patterns", func(t *testing.T) {
+		for _, pattern := range []string{`/{a}{b}`, `/{a:int}{b:alpha}`, `/x-{a}[{b}]`, `/{a}{rest...}`} {
+			_, err := pathmatch.Parse(pattern)
+			require.Error(t, err, `path.Parse(%q) should fail`, pattern)
+		}
+	})
+}
+
+func TestFoldCase(t *testing.T) {
+	testcases := []struct {
+		Pattern  string
+		Input    string
+		Expected pathmatch.Values
+		Error    bool
+	}{
+		{Pattern: `/users/{id}`, Input: `/Users/ABC`, Expected: pathmatch.Values{`id`: `ABC`}},
+		{Pattern: `/users/{id}`, Input: `/USERS/abc`, Expected: pathmatch.Values{`id`: `abc`}},
+		{Pattern: `/users/{id}`, Input: `/userz/abc`, Error: true},
+		{Pattern: `/Files/{name}.JSON`, Input: `/files/Report.json`, Expected: pathmatch.Values{`name`: `Report`}},
+		{Pattern: `/straße`, Input: `/STRAßE`, Expected: pathmatch.Values{}},
+		{Pattern: `/ελλάδα`, Input: `/ΕΛΛΆΔΑ`, Expected: pathmatch.Values{}},
+		{Pattern: `/kelvin`, Input: "/Kelvin", Expected: pathmatch.Values{}},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+` `+tc.Input, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+			p.FoldCase()
+
+			mv, err := p.Match(tc.Input)
+			if tc.Error {
+				require.Error(t, err, `p.Match should fail`)
+				return
+			}
+			require.NoError(t, err, `p.Match should succeed`)
+			require.Equal(t, tc.Expected, mv, `values should match`)
+		})
+	}
+}
+
+func TestCompoundSegmentLongInput(t *testing.T) {
+	// the time needed to match a segment must not grow much faster than
+	// its length, since the length is controlled by clients
+	dots := strings.Repeat(`.`, 16<<10)
+	testcases := []struct {
+		Pattern string
+		Input   string
+	}{
+		{Pattern: `/files/{name}.{ext}.gz`, Input: `/files/` + dots},
+		{Pattern: `/f/{a}.{b}.{c}.x`, Input: `/f/` + dots},
+		{Pattern: `/f/{a}.{b}.{c}.x`, Input: `/f/a` + dots + `x`},
+		{Pattern: `/v{major:int}.{minor:int}.x`, Input: `/v` + strings.Repeat(`1.`, 8<<10)},
+		{Pattern: `/{user}@{rest...}`, Input: `/` + strings.Repeat(`@`, 16<<10) + `/`},
+		{Pattern: `/v{major:int}.{minor:int}`, Input: `/v` + strings.Repeat(`1.`, 8<<10) + `1`},
+		{Pattern: `/{a:[0-9.]+}.{b:[0-9]+}x`, Input: `/` + strings.Repeat(`1.`, 8<<10) + `1`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern, func(t *testing.T) {
+			p, err := pathmatch.Parse(tc.Pattern)
+			require.NoError(t, err, `path.Parse should succeed`)
+
+			start := time.Now()
+			_, _ = p.Match(tc.Input)
+			require.Less(t, time.Since(start), time.Second, `p.Match should not take excessive time`)
+		})
+	}
+
+	t.Run("long values", func(t *testing.T) {
+		p, err := pathmatch.Parse(`/v{major:int}.{minor:int}`)
+		require.NoError(t, err, `path.Parse should succeed`)
+
+		major, minor := strings.Repeat(`1`, 8<<10), strings.Repeat(`2`, 8<<10)
+		mv, err := p.Match(`/v` + major + `.` + minor)
+		require.NoError(t, err, `p.Match should succeed`)
+		require.Equal(t, pathmatch.Values{`major`: major, `minor`: minor}, mv, `values should match`)
+	})
+}
+
+func BenchmarkCompoundSegmentLongInput(b *testing.B) {
+	p, err := pathmatch.Parse(`/f/{a}.{b}.{c}.x`)
+	require.NoError(b, err, `path.Parse should succeed`)
+	input := `/f/` + strings.Repeat(`.`, 4<<10)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Match(input)
+	}
+}
@@ -0,0 +1,443 @@
+package pathmatch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// variableConsumer is implemented by consumers of variable components.
+// Besides consuming input as part of a pattern, they can tell if a value
+// is acceptable on its own, which is needed when several components
+// share a single segment.
+type variableConsumer interface {
+	consumer
+	variableName() string
+	matchValue(string) bool
+}
+
+func (c *segmentConsumer) variableName() string {
+	return c.name
+}
+
+func (c *segmentConsumer) matchValue(s string) bool {
+	return s != "" && strings.IndexByte(s, c.sep) == -1
+}
+
+func (c *typedConsumer) variableName() string {
+	return c.name
+}
+
+func (c *typedConsumer) matchValue(s string) bool {
+	return strings.IndexByte(s, c.sep) == -1 && c.fn(s)
+}
+
+func (c *regexpConsumer) variableName() string {
+	return c.name
+}
+
+// matchValue requires the regular expression to match the entire value,
+// unlike Consume, which extends the match to the end of the segment
+func (c *regexpConsumer) matchValue(s string) bool {
+	return strings.IndexByte(s, c.sep) == -1 && c.value.MatchString(s)
+}
+
+func (c *wildcardConsumer) variableName() string {
+	return c.name
+}
+
+func (c *wildcardConsumer) matchValue(string) bool {
+	return true
+}
+
+// compoundConsumer matches a segment that contains more than one
+// component, such as `{name}.{ext}`. The parts are either literals or
+// variables, and no two variables are adjacent.
+//
+// Variables capture as few bytes as possible, from left to right, while
+// still allowing the rest of the segment to match. For example,
+// `{name}.{ext}` matches `archive.tar.gz` with name set to `archive`,
+// and `{name}.tar.gz` matches `a.b.tar.gz` with name set to `a.b`.
+type compoundConsumer struct {
+	parts []consumer
+	sep   byte
+}
+
+func (c *compoundConsumer) Consume(s string, mv Values) (string, error) {
+	// a segment that ends with a wildcard extends to the end of the input
+	segment, rest := s, ""
+	if _, ok := c.parts[len(c.parts)-1].(*wildcardConsumer); !ok {
+		if i := strings.IndexByte(s, c.sep); i > -1 {
+			segment, rest = s[:i], s[i:]
+		}
+	}
+
+	if !matchParts(c.parts, segment, c.sep, mv) {
+		return s, fmt.Errorf(`failed to match segment %q`, segment)
+	}
+	return rest, nil
+}
+
+// matchParts matches the parts against the entire input.
+//
+// Trying every possible length for every variable takes time that grows
+// quickly with the length of the input, which clients control. Instead,
+// the offsets at which each part may start are computed in two passes:
+// from left to right, the offsets that the preceding parts can reach,
+// and then from right to left, the reachable offsets from which the
+// remaining parts match the rest of the input. Finally, the variables
+// are captured from left to right, each taking the shortest value that
+// allows the remaining parts to match.
+//
+// Literals and variables of the form `{name}` are handled in time
+// proportional to the length of the input. Typed and regular expression
+// variables may need to check many candidate values, so the number of
+// bytes that they check is limited to a multiple of the length of the
+// input, and the match fails if the limit is exceeded.
+func matchParts(parts []consumer, s string, sep byte, mv Values) bool {
+	m := partsMatcher{
+		parts:  parts,
+		s:      s,
+		sep:    sep,
+		budget: checkBudgetFactor*len(s) + checkBudgetBase,
+	}
+	return m.match(mv)
+}
+
+const (
+	checkBudgetFactor = 64
+	checkBudgetBase   = 4096
+)
+
+type partsMatcher struct {
+	parts  []consumer
+	s      string
+	sep    byte
+	budget int
+}
+
+func (m *partsMatcher) match(mv Values) bool {
+	reach, ok := m.forward()
+	if !ok {
+		return false
+	}
+	ok = m.backward(reach)
+	if !ok {
+		return false
+	}
+	return m.capture(reach, mv)
+}
+
+// forward returns reach, where reach[k][p] is true if parts[:k] match s[:p]
+func (m *partsMatcher) forward() ([][]bool, bool) {
+	s, n := m.s, len(m.s)
+	reach := make([][]bool, len(m.parts)+1)
+	reach[0] = make([]bool, n+1)
+	reach[0][0] = true
+	for k, part := range m.parts {
+		cur, next := reach[k], make([]bool, n+1)
+		switch part := part.(type) {
+		case literalConsumer:
+			for p := 0; p+len(part) <= n; p++ {
+				if cur[p] && strings.HasPrefix(s[p:], string(part)) {
+					next[p+len(part)] = true
+				}
+			}
+		case foldLiteralConsumer:
+			for p := 0; p < n; p++ {
+				if !cur[p] {
+					continue
+				}
+				if l, found := hasPrefixFold(s[p:], string(part)); found {
+					next[p+l] = true
+				}
+			}
+		case *wildcardConsumer:
+			for p := range cur {
+				if cur[p] {
+					next[n] = true
+					break
+				}
+			}
+		case *segmentConsumer:
+			// a value may end at q if a reachable offset precedes q
+			// without a separator in between
+			var open bool
+			for q := 0; q <= n; q++ {
+				if q > 0 && s[q-1] == m.sep {
+					open = false
+				}
+				next[q] = open
+				if cur[q] {
+					open = true
+				}
+			}
+		case variableConsumer:
+			// values may only end where the following part may start
+			candidates := nextOffsets(m.starts(k + 1))
+			for p := range cur {
+				if !cur[p] {
+					continue
+				}
+				for q := p + 1; q <= m.end(p); q++ {
+					if q = candidates[q]; q < 0 || q > m.end(p) {
+						break
+					}
+					if m.check(part, p, q) {
+						next[q] = true
+					}
+					if m.budget < 0 {
+						return nil, false
+					}
+				}
+			}
+		default:
+			return nil, false
+		}
+		reach[k+1] = next
+	}
+	return reach, reach[len(m.parts)][n]
+}
+
+// backward narrows reach down, so that reach[k][p] is only true if
+// parts[:k] match s[:p] and parts[k:] match s[p:]
+func (m *partsMatcher) backward(reach [][]bool) bool {
+	s, n := m.s, len(m.s)
+	last := reach[len(m.parts)]
+	for p := range last {
+		last[p] = p == n
+	}
+	for k := len(m.parts) - 1; k >= 0; k-- {
+		cur, next := reach[k], reach[k+1]
+		switch part := m.parts[k].(type) {
+		case literalConsumer:
+			for p := range cur {
+				cur[p] = cur[p] && p+len(part) <= n && next[p+len(part)] && strings.HasPrefix(s[p:], string(part))
+			}
+		case foldLiteralConsumer:
+			for p := range cur {
+				if cur[p] {
+					l, found := hasPrefixFold(s[p:], string(part))
+					cur[p] = found && next[p+l]
+				}
+			}
+		case *wildcardConsumer:
+			for p := range cur {
+				cur[p] = cur[p] && next[n]
+			}
+		case *segmentConsumer:
+			// q is the closest offset after p where the rest may start,
+			// as long as no separator lies in between
+			q := -1
+			for p := n; p >= 0; p-- {
+				if p < n && s[p] == m.sep {
+					q = -1
+				}
+				cur[p] = cur[p] && q > -1
+				if next[p] {
+					q = p
+				}
+			}
+		case variableConsumer:
+			following := nextOffsets(next)
+			for p := range cur {
+				if cur[p] {
+					_, cur[p] = m.shortestValue(part, p, following)
+				}
+				if m.budget < 0 {
+					return false
+				}
+			}
+		}
+	}
+	return reach[0][0]
+}
+
+// capture assigns the values of the variables, using reach as computed
+// by backward
+func (m *partsMatcher) capture(reach [][]bool, mv Values) bool {
+	s := m.s
+	var p int
+	for k, part := range m.parts {
+		switch part := part.(type) {
+		case literalConsumer:
+			p += len(part)
+		case foldLiteralConsumer:
+			l, _ := hasPrefixFold(s[p:], string(part))
+			p += l
+		case *wildcardConsumer:
+			mv[part.name] = s[p:]
+			p = len(s)
+		case variableConsumer:
+			q, found := m.shortestValue(part, p, nextOffsets(reach[k+1]))
+			if !found {
+				return false
+			}
+			mv[part.variableName()] = s[p:q]
+			p = q
+		}
+	}
+	return true
+}
+
+// starts returns the offsets at which the k-th part may start, judging
+// only by the part itself. Since variables are never adjacent, the part
+// is either a literal, or the end of the input
+func (m *partsMatcher) starts(k int) []bool {
+	s, n := m.s, len(m.s)
+	result := make([]bool, n+1)
+	if k == len(m.parts) {
+		result[n] = true
+		return result
+	}
+	for p := range result {
+		switch part := m.parts[k].(type) {
+		case literalConsumer:
+			result[p] = strings.HasPrefix(s[p:], string(part))
+		case foldLiteralConsumer:
+			_, result[p] = hasPrefixFold(s[p:], string(part))
+		default:
+			result[p] = true
+		}
+	}
+	return result
+}
+
+// end returns the offset of the first separator at or after p, or the
+// length of the input. None of the variables accept values that contain
+// separators
+func (m *partsMatcher) end(p int) int {
+	if i := strings.IndexByte(m.s[p:], m.sep); i > -1 {
+		return p + i
+	}
+	return len(m.s)
+}
+
+// check returns true if s[p:q] is a valid value for the variable. The
+// length of the value is deducted from the budget
+func (m *partsMatcher) check(part variableConsumer, p, q int) bool {
+	if _, ok := part.(*segmentConsumer); ok {
+		return true
+	}
+	if m.budget -= q - p; m.budget < 0 {
+		return false
+	}
+	return part.matchValue(m.s[p:q])
+}
+
+// shortestValue returns the smallest offset q such that s[p:q] is a
+// valid value for the variable, and the remaining parts match from q.
+// `following` is the result of nextOffsets for the remaining parts
+func (m *partsMatcher) shortestValue(part variableConsumer, p int, following []int) (int, bool) {
+	end := m.end(p)
+	for q := p + 1; q <= end; q++ {
+		if q = following[q]; q < 0 || q > end {
+			break
+		}
+		if m.check(part, p, q) {
+			return q, true
+		}
+		if m.budget < 0 {
+			break
+		}
+	}
+	return 0, false
+}
+
+// nextOffsets returns, for every offset, the smallest offset that is not
+// before it and at which ok is true, or -1 if there is none
+func nextOffsets(ok []bool) []int {
+	result := make([]int, len(ok))
+	q := -1
+	for p := len(ok) - 1; p >= 0; p-- {
+		if ok[p] {
+			q = p
+		}
+		result[p] = q
+	}
+	return result
+}
+
+// segmentConsumers creates the consumers for a flattened list of
+// expressions. Variables that share a segment with other components
+// are grouped into a compoundConsumer.
+func (p *Matcher) segmentConsumers(exprs []Expression, sep byte) ([]consumer, error) {
+	var consumers []consumer
+	var compound *compoundConsumer
+	for i, expr := range exprs {
+		if lit, ok := expr.(*Literal); ok {
+			s := lit.Lit
+			if compound != nil {
+				// the compound segment ends at the next separator
+				j := strings.IndexByte(s, sep)
+				if j == -1 {
+					compound.parts = append(compound.parts, literalConsumer(s))
+					continue
+				}
+				if j > 0 {
+					compound.parts = append(compound.parts, literalConsumer(s[:j]))
+				}
+				consumers = append(consumers, compound)
+				compound = nil
+				s = s[j:]
+			}
+			consumers = append(consumers, literalConsumer(s))
+			continue
+		}
+
+		c, ok := p.consumers[expr].(variableConsumer)
+		if !ok {
+			return nil, fmt.Errorf(`invalid expression %T`, expr)
+		}
+
+		if compound != nil {
+			if prev, ok := compound.parts[len(compound.parts)-1].(variableConsumer); ok {
+				return nil, fmt.Errorf(`variables %q and %q must be separated by a literal`, prev.variableName(), c.variableName())
+			}
+			compound.parts = append(compound.parts, c)
+			continue
+		}
+
+		if !sharesSegment(exprs[i+1:], sep) {
+			consumers = append(consumers, c)
+			continue
+		}
+		compound = &compoundConsumer{
+			parts: []consumer{c},
+			sep:   sep,
+		}
+	}
+	if compound != nil {
+		consumers = append(consumers, compound)
+	}
+	return consumers, nil
+}
+
+// sharesSegment returns true if the expressions following a variable
+// continue the segment that the variable is in
+func sharesSegment(exprs []Expression, sep byte) bool {
+	if len(exprs) == 0 {
+		return false
+	}
+	if lit, ok := exprs[0].(*Literal); ok {
+		return lit.Lit != "" && lit.Lit[0] != sep
+	}
+	return true
+}
+
+// mergeLiterals merges adjacent literals, which may happen when an
+// optional section starts or ends with a literal
+func mergeLiterals(exprs []Expression) []Expression {
+	result := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		if lit, ok := expr.(*Literal); ok {
+			if n := len(result); n > 0 {
+				if prev, ok := result[n-1].(*Literal); ok {
+					result[n-1] = NewLiteral(prev.Lit + lit.Lit)
+					continue
+				}
+			}
+		}
+		result = append(result, expr)
+	}
+	return result
+}
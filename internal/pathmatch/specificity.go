@@ -0,0 +1,62 @@
+package pathmatch
+
+// CompareSpecificity ranks two Matchers by how specific their patterns
+// are. It returns a negative number if `a` is more specific than `b`,
+// a positive number if `b` is more specific than `a`, and zero if they
+// are equally specific. If aPrefix or bPrefix is true, the corresponding
+// Matcher is treated as a prefix, as if it were followed by a wildcard.
+//
+// Patterns are compared segment by segment, from left to right, and the
+// first segment that differs decides. Segments are ranked as follows,
+// from the most specific to the least specific:
+//
+//	literal segments, such as `users`
+//	segments mixing literals and variables, such as `{name}.json`
+//	typed and regular expression variables, such as `{id:int}`
+//	plain variables, such as `{id}`
+//	wildcards, such as `{path...}`
+//
+// If one pattern is a prefix of the other, the shorter pattern is more
+// specific. Optional sections are compared as if they were present.
+func CompareSpecificity(a *Matcher, aPrefix bool, b *Matcher, bPrefix bool) int {
+	as := a.specificity(aPrefix)
+	bs := b.specificity(bPrefix)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return bs[i] - as[i]
+		}
+	}
+	return len(as) - len(bs)
+}
+
+const (
+	rankWildcard = iota
+	rankPlain
+	rankConstrained
+	rankMixed
+	rankLiteral
+)
+
+func (p *Matcher) specificity(prefix bool) []int {
+	seq := p.sequence(p.variants[0], prefix)
+	ranks := make([]int, len(seq))
+	for i, s := range seq {
+		ranks[i] = s.rank()
+	}
+	return ranks
+}
+
+func (s segment) rank() int {
+	switch {
+	case s.parts == nil:
+		return rankLiteral
+	case s.wildcard():
+		return rankWildcard
+	case len(s.parts) > 1:
+		return rankMixed
+	case s.plain():
+		return rankPlain
+	default:
+		return rankConstrained
+	}
+}
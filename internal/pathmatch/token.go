@@ -4,28 +4,104 @@ type Literal struct {
 	Lit string
 }
 
-type LiteralPattern struct {
+// Variable holds the attributes shared by all variable components
+type Variable struct {
 	Name string
+
+	// Optional is true if the variable was declared as `{name?}` or
+	// `{name=default}`
+	Optional bool
+
+	// Default is the value of the variable when it is absent from the
+	// input. It is only meaningful if HasDefault is true
+	Default    string
+	HasDefault bool
+}
+
+func (v *Variable) variable() *Variable {
+	return v
+}
+
+type LiteralPattern struct {
+	Variable
 }
 
 type RegexpPattern struct {
-	Name    string
+	Variable
 	Pattern string
 }
 
+// TypedPattern is a variable component whose value must satisfy a named
+// type, such as `{id:int}`. Types are registered using `RegisterType`
+type TypedPattern struct {
+	Variable
+	Type string
+}
+
+// Wildcard is a variable component that captures the remainder of the
+// input, including slashes, as in `{path...}`. It may only appear at the
+// end of a pattern
+type Wildcard struct {
+	Variable
+}
+
+// Optional is a section of the pattern that may be absent from the
+// input, as in `/posts[/{page}]`
+type Optional struct {
+	Exprs []Expression
+}
+
+// variableExpression is implemented by all variable components
+type variableExpression interface {
+	variable() *Variable
+}
+
+func NewTypedPattern(name string, typ string) Expression {
+	return &TypedPattern{
+		Variable: Variable{Name: name},
+		Type:     typ,
+	}
+}
+
 func NewLiteralPattern(s string) Expression {
-	return &LiteralPattern{Name: s}
+	return &LiteralPattern{Variable: Variable{Name: s}}
 }
 
 func NewRegexpPattern(name string, pattern string) Expression {
 	return &RegexpPattern{
-		Name:    name,
-		Pattern: pattern,
+		Variable: Variable{Name: name},
+		Pattern:  pattern,
 	}
 }
 
+func NewWildcard(name string) Expression {
+	return &Wildcard{Variable: Variable{Name: name}}
+}
+
 func NewLiteral(s string) Expression {
 	return &Literal{
 		Lit: s,
 	}
 }
+
+func NewOptional(exprs []Expression) Expression {
+	return &Optional{
+		Exprs: exprs,
+	}
+}
+
+func markOptional(e Expression) Expression {
+	if v, ok := e.(variableExpression); ok {
+		v.variable().Optional = true
+	}
+	return e
+}
+
+func markDefault(e Expression, def string) Expression {
+	if v, ok := e.(variableExpression); ok {
+		v.variable().Optional = true
+		v.variable().Default = def
+		v.variable().HasDefault = true
+	}
+	return e
+}
@@ -17,13 +17,15 @@ type position struct {
 }
 
 type tokenizer struct {
-	src          *strings.Reader
-	expectRegexp bool
-	eof          bool
-	offset       int
-	lineHead     int
-	line         int
-	buf          rune // no backtracking, so 1 rune is enough
+	src           *strings.Reader
+	inBrace       bool
+	expectRegexp  bool
+	expectDefault bool
+	eof           bool
+	offset        int
+	lineHead      int
+	line          int
+	buf           rune // no backtracking, so 1 rune is enough
 }
 
 func newTokenizer(s string) *tokenizer {
@@ -72,22 +74,64 @@ func (t *tokenizer) Token() (int, interface{}, position, error) {
 	var tok int
 	var lit interface{}
 	r := t.peek()
-	switch r {
-	case utf8.RuneError:
+
+	// the text following an equal sign inside braces is the default value,
+	// which may be empty
+	if t.expectDefault && r != utf8.RuneError {
+		t.expectDefault = false
+		return tLiteral, t.readUntil('}'), pos, nil
+	}
+
+	switch {
+	case r == utf8.RuneError:
 		return tEOF, nil, pos, io.EOF
-	case '{':
+	case r == '{':
 		tok = tOpenBrace
 		lit = "{"
 		t.next()
-	case '}':
+		t.inBrace = true
+	case r == '}':
 		tok = tCloseBrace
 		lit = "}"
 		t.next()
-	case ':':
+		t.inBrace = false
+	case r == ':':
 		tok = tColon
 		lit = ":"
 		t.next()
 		t.expectRegexp = true
+	case t.inBrace && !t.expectRegexp && r == '?':
+		tok = tQuestion
+		lit = "?"
+		t.next()
+	case t.inBrace && !t.expectRegexp && r == '=':
+		tok = tEquals
+		lit = "="
+		t.next()
+		t.expectDefault = true
+	case t.inBrace && !t.expectRegexp && r == '.':
+		// the only place where a dot may appear in a variable is
+		// the ellipsis that denotes a wildcard, as in `{path...}`.
+		// any other sequence of dots is a literal, which the parser
+		// rejects
+		lit = t.dots()
+		tok = tLiteral
+		if lit == "..." {
+			tok = tEllipsis
+		}
+	case !t.inBrace && r == '[':
+		tok = tOpenBracket
+		lit = "["
+		t.next()
+	case !t.inBrace && r == ']':
+		tok = tCloseBracket
+		lit = "]"
+		t.next()
+	case t.expectRegexp:
+		// the text following a colon is either the name of a type,
+		// or a regular expression
+		t.expectRegexp = false
+		tok, lit = t.typeOrRegexp()
 	default:
 		tok = tLiteral
 		lit = t.literal()
@@ -107,27 +151,92 @@ func (t *tokenizer) skipWhitespace() {
 }
 func (t *tokenizer) literal() string {
 	var b strings.Builder
-LOOP:
 	for {
 		r := t.peek()
-		if t.expectRegexp {
-			switch r {
-			case '}', utf8.RuneError:
-				break LOOP
-			default:
-				b.WriteRune(r)
-				t.next()
+		switch r {
+		case ':', '{', '}', utf8.RuneError:
+			return b.String()
+		case '?', '=', '.':
+			if t.inBrace {
+				return b.String()
 			}
-		} else {
-			switch r {
-			case ':', '{', '}', utf8.RuneError:
-				break LOOP
-			default:
-				b.WriteRune(r)
-				t.next()
+		case '[', ']':
+			if !t.inBrace {
+				return b.String()
 			}
 		}
+		b.WriteRune(r)
+		t.next()
+	}
+}
+
+func (t *tokenizer) dots() string {
+	var b strings.Builder
+	for t.peek() == '.' {
+		b.WriteRune('.')
+		t.next()
 	}
-	t.expectRegexp = false
 	return b.String()
 }
+
+// typeOrRegexp reads the text following a colon. If the text is an
+// identifier followed by the end of the variable, a question mark, or
+// an equal sign, it is the name of a type. Otherwise the text up to the
+// closing brace is a regular expression
+func (t *tokenizer) typeOrRegexp() (int, string) {
+	var b strings.Builder
+	for {
+		r := t.peek()
+		if r == utf8.RuneError || !isTypeNameRune(r, b.Len() == 0) {
+			break
+		}
+		b.WriteRune(r)
+		t.next()
+	}
+
+	switch t.peek() {
+	case '}', '?', '=':
+		if b.Len() > 0 {
+			return tTypeName, b.String()
+		}
+	}
+
+	b.WriteString(t.readUntil('}'))
+	return tLiteral, b.String()
+}
+
+// readUntil reads the text up to, but not including, the delimiter or EOF
+func (t *tokenizer) readUntil(delim rune) string {
+	var b strings.Builder
+	for {
+		r := t.peek()
+		if r == utf8.RuneError || r == delim {
+			return b.String()
+		}
+		b.WriteRune(r)
+		t.next()
+	}
+}
+
+// isTypeName returns true if s is an identifier, such as `int` or `uuid`
+func isTypeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isTypeNameRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTypeNameRune(r rune, first bool) bool {
+	switch {
+	case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return true
+	case !first && r >= '0' && r <= '9':
+		return true
+	}
+	return false
+}
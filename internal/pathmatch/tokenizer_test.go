@@ -142,6 +142,69 @@ func TestTokenizer(t *testing.T) {
 				},
 			},
 		},
+		{
+			Input: "{id:int}",
+			Expected: []TokReturn{
+				{
+					Tok: tOpenBrace,
+					Lit: "{",
+					Pos: position{
+						line: 1,
+						col:  1,
+					},
+				},
+				{
+					Tok: tLiteral,
+					Lit: "id",
+					Pos: position{
+						line: 1,
+						col:  2,
+					},
+				},
+				{
+					Tok: tColon,
+					Lit: ":",
+					Pos: position{
+						line: 1,
+						col:  4,
+					},
+				},
+				{
+					Tok: tTypeName,
+					Lit: "int",
+					Pos: position{
+						line: 1,
+						col:  5,
+					},
+				},
+				{
+					Tok: tCloseBrace,
+					Lit: "}",
+					Pos: position{
+						line: 1,
+						col:  8,
+					},
+				},
+				{
+					Tok: tEOF,
+					Pos: position{
+						line: 1,
+						col:  9,
+					},
+					Err: io.EOF,
+				},
+			},
+		},
+		{
+			Input: "{path...}",
+			Expected: []TokReturn{
+				{Tok: tOpenBrace, Lit: "{", Pos: position{line: 1, col: 1}},
+				{Tok: tLiteral, Lit: "path", Pos: position{line: 1, col: 2}},
+				{Tok: tEllipsis, Lit: "...", Pos: position{line: 1, col: 6}},
+				{Tok: tCloseBrace, Lit: "}", Pos: position{line: 1, col: 9}},
+				{Tok: tEOF, Pos: position{line: 1, col: 10}, Err: io.EOF},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
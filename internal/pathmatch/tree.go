@@ -0,0 +1,343 @@
+package pathmatch
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tree is a prefix tree built from the consumers of one or more Matchers.
+//
+// Literal components are merged into a radix tree keyed by their text,
+// while variable components become children of the node that precedes
+// them. Every Matcher inserted into the tree is associated with an
+// integer index, and lookups return the smallest index whose Matcher
+// matches the input. This allows callers to express precedence
+// (e.g. registration order) simply by the order of the indices.
+//
+// Nodes are never modified once they have been added to a tree. Instead,
+// insertions copy the nodes along the path that they modify. This makes
+// Clone cheap, and allows a clone to be modified while the original is
+// being used by other goroutines.
+type Tree struct {
+	root *node
+}
+
+type node struct {
+	// prefix is the literal text that must be consumed to enter this node.
+	// It is empty for the root node and for dynamic nodes.
+	prefix string
+
+	// consumer is the variable component that must be consumed to enter
+	// this node. It is nil for literal nodes.
+	consumer consumer
+	key      string
+
+	statics  []*node
+	dynamics []*node
+
+	// folds contains the children that are entered by matching their
+	// prefix regardless of case. Their prefixes are in folded form, and
+	// no two of them start with the same rune
+	folds []*node
+
+	// leaves contains the indices of the Matchers that end at this node,
+	// in ascending order
+	leaves []int
+
+	// prefixes contains the indices of the Matchers that were inserted
+	// as prefixes, and end at this node, in ascending order
+	prefixes []int
+
+	// min is the smallest index stored in this subtree
+	min int
+}
+
+func NewTree() *Tree {
+	return &Tree{
+		root: &node{min: math.MaxInt},
+	}
+}
+
+// Clone returns a copy of the tree. Inserting into the copy does not
+// affect the original, and vice versa.
+func (t *Tree) Clone() *Tree {
+	return &Tree{root: t.root}
+}
+
+// Insert adds the Matcher to the tree, associated with the given index.
+// Indices must be non-negative, and may be inserted in any order.
+func (t *Tree) Insert(m *Matcher, idx int) {
+	for _, v := range m.variants {
+		t.root = t.root.insert(v.consumers, idx, false)
+	}
+}
+
+// InsertPrefix adds the Matcher to the tree as a prefix, associated with
+// the given index. A prefix matches any input where the match ends at a
+// path boundary: either at the end of the input, or right before or after
+// a slash (`/`).
+func (t *Tree) InsertPrefix(m *Matcher, idx int) {
+	for _, v := range m.variants {
+		t.root = t.root.insert(v.consumers, idx, true)
+	}
+}
+
+// Lookup returns the smallest index whose associated Matcher matches the
+// input `s`, and for which `accept` returns true. If no such index exists,
+// -1 is returned.
+func (t *Tree) Lookup(s string, accept func(int) bool) int {
+	best := math.MaxInt
+	t.root.lookup(s, s, accept, make(Values), &best)
+	if best == math.MaxInt {
+		return -1
+	}
+	return best
+}
+
+// insert returns a copy of the node with the consumers added to it
+func (n *node) insert(consumers []consumer, idx int, prefix bool) *node {
+	c := *n
+	c.add(consumers, idx, prefix)
+	return &c
+}
+
+// add adds the consumers to the node. The node must not be part of a
+// tree yet, but its children may be, and are therefore copied as needed
+func (n *node) add(consumers []consumer, idx int, prefix bool) {
+	if idx < n.min {
+		n.min = idx
+	}
+
+	if len(consumers) == 0 {
+		// the same index may be inserted more than once at the same
+		// node by different variants of a Matcher
+		if prefix {
+			n.prefixes = insertIndex(n.prefixes, idx)
+		} else {
+			n.leaves = insertIndex(n.leaves, idx)
+		}
+		return
+	}
+
+	switch lit := consumers[0].(type) {
+	case literalConsumer:
+		n.addLiteral(string(lit), consumers[1:], idx, prefix, false)
+		return
+	case foldLiteralConsumer:
+		n.addLiteral(string(lit), consumers[1:], idx, prefix, true)
+		return
+	}
+
+	key := consumerKey(consumers[0])
+	for i, child := range n.dynamics {
+		if child.key == key {
+			n.dynamics = replaceNode(n.dynamics, i, child.insert(consumers[1:], idx, prefix))
+			return
+		}
+	}
+
+	child := &node{
+		consumer: consumers[0],
+		key:      key,
+		min:      math.MaxInt,
+	}
+	child.add(consumers[1:], idx, prefix)
+	n.dynamics = append(n.dynamics[:len(n.dynamics):len(n.dynamics)], child)
+}
+
+// addLiteral adds the consumers following a literal to the node. If fold
+// is true, the literal is in folded form, and is added to the children
+// that are matched regardless of case
+func (n *node) addLiteral(lit string, rest []consumer, idx int, prefix, fold bool) {
+	if lit == "" {
+		n.add(rest, idx, prefix)
+		return
+	}
+
+	children := &n.statics
+	if fold {
+		children = &n.folds
+	}
+
+	for i, child := range *children {
+		l := commonPrefixLength(child.prefix, lit)
+		if fold {
+			// folded prefixes are compared rune by rune, so they may
+			// not be split in the middle of a rune
+			for l > 0 && l < len(child.prefix) && !utf8.RuneStart(child.prefix[l]) {
+				l--
+			}
+		}
+		if l == 0 {
+			continue
+		}
+
+		var c node
+		if l < len(child.prefix) {
+			// split the child so that the common part becomes its own node
+			tail := *child
+			tail.prefix = child.prefix[l:]
+			c = node{
+				prefix: child.prefix[:l],
+				min:    child.min,
+			}
+			if fold {
+				c.folds = []*node{&tail}
+			} else {
+				c.statics = []*node{&tail}
+			}
+		} else {
+			c = *child
+		}
+
+		if idx < c.min {
+			c.min = idx
+		}
+		c.addLiteral(lit[l:], rest, idx, prefix, fold)
+		*children = replaceNode(*children, i, &c)
+		return
+	}
+
+	child := &node{
+		prefix: lit,
+		min:    idx,
+	}
+	child.add(rest, idx, prefix)
+	*children = append((*children)[:len(*children):len(*children)], child)
+}
+
+func (n *node) lookup(input, s string, accept func(int) bool, scratch Values, best *int) {
+	if n.min >= *best {
+		return
+	}
+
+	if s == "" {
+		acceptLowest(n.leaves, accept, best)
+	}
+	if atBoundary(input, s) {
+		acceptLowest(n.prefixes, accept, best)
+	}
+
+	if s != "" {
+		for _, child := range n.statics {
+			if child.prefix[0] != s[0] {
+				continue
+			}
+			if strings.HasPrefix(s, child.prefix) {
+				child.lookup(input, s[len(child.prefix):], accept, scratch, best)
+			}
+			// there can only be one static child starting with the same byte
+			break
+		}
+
+		if len(n.folds) > 0 {
+			r, _ := utf8.DecodeRuneInString(s)
+			r = foldRune(r)
+			for _, child := range n.folds {
+				if c, _ := utf8.DecodeRuneInString(child.prefix); c != r {
+					continue
+				}
+				if l, ok := hasPrefixFold(s, child.prefix); ok {
+					child.lookup(input, s[l:], accept, scratch, best)
+				}
+				break
+			}
+		}
+	}
+
+	for _, child := range n.dynamics {
+		if child.min >= *best {
+			continue
+		}
+		rest, err := child.consumer.Consume(s, scratch)
+		if err != nil {
+			continue
+		}
+		child.lookup(input, rest, accept, scratch, best)
+	}
+}
+
+// atBoundary returns true if `rest`, which is the unprocessed portion of
+// `input`, starts at a path boundary
+func atBoundary(input, rest string) bool {
+	if rest == "" || rest[0] == '/' {
+		return true
+	}
+	consumed := len(input) - len(rest)
+	return consumed > 0 && input[consumed-1] == '/'
+}
+
+func acceptLowest(indices []int, accept func(int) bool, best *int) {
+	for _, idx := range indices {
+		if idx >= *best {
+			return
+		}
+		if accept(idx) {
+			*best = idx
+			return
+		}
+	}
+}
+
+// insertIndex returns a copy of the sorted indices with idx added
+func insertIndex(indices []int, idx int) []int {
+	i := sort.SearchInts(indices, idx)
+	if i < len(indices) && indices[i] == idx {
+		return indices
+	}
+	result := make([]int, 0, len(indices)+1)
+	result = append(result, indices[:i]...)
+	result = append(result, idx)
+	return append(result, indices[i:]...)
+}
+
+// replaceNode returns a copy of the nodes with the i-th node replaced
+func replaceNode(nodes []*node, i int, n *node) []*node {
+	result := make([]*node, len(nodes))
+	copy(result, nodes)
+	result[i] = n
+	return result
+}
+
+func consumerKey(c consumer) string {
+	switch c := c.(type) {
+	case *segmentConsumer:
+		return `segment`
+	case *typedConsumer:
+		return `type:` + c.typ
+	case *regexpConsumer:
+		return `regexp:` + c.pattern.String()
+	case *wildcardConsumer:
+		return `wildcard`
+	case foldLiteralConsumer:
+		return `fold:` + strconv.Quote(string(c))
+	case *compoundConsumer:
+		keys := make([]string, 0, len(c.parts))
+		for _, part := range c.parts {
+			if lit, ok := part.(literalConsumer); ok {
+				keys = append(keys, strconv.Quote(string(lit)))
+				continue
+			}
+			keys = append(keys, consumerKey(part))
+		}
+		return `compound:` + strings.Join(keys, `,`)
+	default:
+		return ``
+	}
+}
+
+func commonPrefixLength(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package pathmatch_test
+
+import (
+	"testing"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	patterns := []string{
+		`/foo/bar/{id}`,
+		`/foo/bar/baz`,
+		`/foo/baz/{id:^[0-9]+$}`,
+		`/foo/{name}/view`,
+		`/foo/bar/{id}/view`,
+		`/fob`,
+		`/`,
+		`/files/{name}.{ext}`,
+		`/files/{name}`,
+	}
+
+	tree := pathmatch.NewTree()
+	for i, pattern := range patterns {
+		m, err := pathmatch.Parse(pattern)
+		require.NoError(t, err, `pathmatch.Parse should succeed`)
+		tree.Insert(m, i)
+	}
+
+	testcases := []struct {
+		Input    string
+		Expected int
+	}{
+		{Input: `/foo/bar/123`, Expected: 0},
+		{Input: `/foo/bar/baz`, Expected: 0},
+		{Input: `/foo/baz/123`, Expected: 2},
+		{Input: `/foo/baz/abc`, Expected: -1},
+		{Input: `/foo/baz/view`, Expected: 3},
+		{Input: `/foo/bar/view`, Expected: 0},
+		{Input: `/foo/bar/123/view`, Expected: 4},
+		{Input: `/fob`, Expected: 5},
+		{Input: `/fo`, Expected: -1},
+		{Input: `/`, Expected: 6},
+		{Input: ``, Expected: -1},
+		{Input: `/files/report.pdf`, Expected: 7},
+		{Input: `/files/report`, Expected: 8},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Input, func(t *testing.T) {
+			idx := tree.Lookup(tc.Input, func(int) bool { return true })
+			require.Equal(t, tc.Expected, idx, `tree.Lookup should return the expected index`)
+		})
+	}
+
+	t.Run("accept", func(t *testing.T) {
+		idx := tree.Lookup(`/foo/bar/baz`, func(idx int) bool { return idx != 0 })
+		require.Equal(t, 1, idx, `tree.Lookup should skip indices that were not accepted`)
+	})
+	t.Run("fold case", func(t *testing.T) {
+		tree := pathmatch.NewTree()
+		for i, pattern := range []string{`/Users/me`, `/users/{id}`, `/über`, `/übung`, `/files/{name}.JSON`} {
+			m, err := pathmatch.Parse(pattern)
+			require.NoError(t, err, `pathmatch.Parse should succeed`)
+			if i > 0 {
+				m.FoldCase()
+			}
+			tree.Insert(m, i)
+		}
+
+		testcases := []struct {
+			Input    string
+			Expected int
+		}{
+			{Input: `/Users/me`, Expected: 0},
+			{Input: `/users/me`, Expected: 1},
+			{Input: `/USERS/123`, Expected: 1},
+			{Input: `/ÜBER`, Expected: 2},
+			{Input: `/Übung`, Expected: 3},
+			{Input: `/übel`, Expected: -1},
+			{Input: `/Files/report.json`, Expected: 4},
+		}
+		for _, tc := range testcases {
+			idx := tree.Lookup(tc.Input, func(int) bool { return true })
+			require.Equal(t, tc.Expected, idx, `tree.Lookup(%q) should return the expected index`, tc.Input)
+		}
+	})
+	t.Run("clone", func(t *testing.T) {
+		m, err := pathmatch.Parse(`/fo`)
+		require.NoError(t, err, `pathmatch.Parse should succeed`)
+
+		// indices may be inserted out of order, and inserting into the
+		// clone must not affect the original tree
+		clone := tree.Clone()
+		clone.Insert(m, 3)
+		require.Equal(t, 3, clone.Lookup(`/fo`, func(int) bool { return true }), `clone should find the new index`)
+		require.Equal(t, -1, tree.Lookup(`/fo`, func(int) bool { return true }), `original tree should not be modified`)
+		require.Equal(t, 5, clone.Lookup(`/fob`, func(int) bool { return true }), `clone should find existing indices`)
+	})
+}
@@ -0,0 +1,141 @@
+package pathmatch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TypeFunc reports whether a path segment is a valid value for a type
+type TypeFunc func(string) bool
+
+var typesMu sync.RWMutex
+var types = map[string]TypeFunc{
+	`int`:   isInt,
+	`uint`:  isUint,
+	`uuid`:  isUUID,
+	`alpha`: isAlpha,
+	`alnum`: isAlnum,
+	`hex`:   isHex,
+	`date`:  isDate,
+}
+
+// RegisterType registers a type that can be used in patterns of the
+// form `{name:type}`. The name must be an identifier, and may not
+// already be registered.
+func RegisterType(name string, fn TypeFunc) error {
+	if !isTypeName(name) {
+		return fmt.Errorf(`invalid type name %q`, name)
+	}
+	if fn == nil {
+		return fmt.Errorf(`type %q must have a non-nil function`, name)
+	}
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if _, ok := types[name]; ok {
+		return fmt.Errorf(`type %q is already registered`, name)
+	}
+	types[name] = fn
+	return nil
+}
+
+func lookupType(name string) (TypeFunc, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	fn, ok := types[name]
+	return fn, ok
+}
+
+func isUint(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigitByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isInt(s string) bool {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return isUint(s)
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlphaByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlphaByte(s[i]) && !isDigitByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isHexByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID accepts the canonical 8-4-4-4-12 form of a UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexByte(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDate accepts dates in the form YYYY-MM-DD
+func isDate(s string) bool {
+	_, err := time.Parse(`2006-01-02`, s)
+	return err == nil
+}
+
+func isAlphaByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexByte(c byte) bool {
+	return isDigitByte(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
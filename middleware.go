@@ -0,0 +1,49 @@
+package mux
+
+import "net/http"
+
+// Middleware wraps an http.Handler to add behavior before and/or after
+// it is invoked.
+//
+// Middlewares are invoked after the route has been matched, so the
+// variables captured from the path are available through `mux.Vars`.
+type Middleware func(http.Handler) http.Handler
+
+// Use adds middlewares that are applied to all routes in the Router,
+// including routes that were registered before Use was called.
+//
+// Middlewares are invoked in the order that they were added, with the
+// Router's middlewares running first, followed by those of the Group
+// (outermost group first), and finally those specified with
+// `mux.WithMiddleware` for the route.
+//
+// Middlewares are applied to the handlers when routes are registered or
+// when middlewares are added, rather than on every request.
+//
+// Middlewares are only invoked for requests that matched a route. They
+// are not invoked for requests handled by `Router.NotFound`,
+// `Router.MethodNotAllowed`, or automatic OPTIONS responses.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+	r.republish()
+}
+
+// chain wraps the handler of the route with all middlewares that apply
+// to it. It must be called while holding the lock
+func (r *Router) chain(p *path) http.Handler {
+	hh := wrap(p.handler, p.middlewares)
+	for g := p.group; g != nil; g = g.parent {
+		hh = wrap(hh, g.middlewares)
+	}
+	return wrap(hh, r.middlewares)
+}
+
+// wrap applies the middlewares such that the first one is the outermost
+func wrap(hh http.Handler, middlewares []Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		hh = middlewares[i](hh)
+	}
+	return hh
+}
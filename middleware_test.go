@@ -0,0 +1,71 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	trace := func(name string) mux.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add(`X-Trace`, fmt.Sprintf(`%s(id=%s)`, name, mux.Vars(r).Get(`id`)))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var r mux.Router
+	r.Use(trace(`router1`), trace(`router2`))
+	require.NoError(t, r.Get(`/plain/{id}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/route/{id}`, noop, mux.WithMiddleware(trace(`route1`), trace(`route2`))), `r.Get should succeed`)
+
+	api := r.Group(`/api`)
+	api.Use(trace(`api`))
+	v1 := api.Group(`/v1`)
+	require.NoError(t, v1.Get(`/users/{id}`, noop, mux.WithMiddleware(trace(`route`))), `v1.Get should succeed`)
+	// middlewares added after the route was registered still apply
+	v1.Use(trace(`v1`))
+
+	testcases := []struct {
+		Path     string
+		Status   int
+		Expected []string
+	}{
+		{
+			Path:     `/plain/1`,
+			Status:   http.StatusOK,
+			Expected: []string{`router1(id=1)`, `router2(id=1)`},
+		},
+		{
+			Path:     `/route/2`,
+			Status:   http.StatusOK,
+			Expected: []string{`router1(id=2)`, `router2(id=2)`, `route1(id=2)`, `route2(id=2)`},
+		},
+		{
+			Path:     `/api/v1/users/3`,
+			Status:   http.StatusOK,
+			Expected: []string{`router1(id=3)`, `router2(id=3)`, `api(id=3)`, `v1(id=3)`, `route(id=3)`},
+		},
+		{
+			Path:   `/notfound`,
+			Status: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			require.Equal(t, strings.Join(tc.Expected, `,`), strings.Join(w.Header().Values(`X-Trace`), `,`), `middlewares should be invoked in order`)
+		})
+	}
+}
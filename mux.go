@@ -3,19 +3,51 @@ package mux
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/lestrrat-go/mux/internal/pathmatch"
+	"golang.org/x/text/unicode/norm"
 )
 
 type identMatchValues struct{}
+type identAllowedMethods struct{}
+type identMountPrefix struct{}
+type identRoute struct{}
 
 // Values is the interface that allows users to access the
 // variable path components in the given path. Use `mux.Vars`
 // to access this structure
+//
+// The typed accessors return a *VarError if the variable does
+// not exist, or if its value cannot be converted.
 type Values interface {
+	// Get returns the value of the variable, or an empty string
+	// if it does not exist
 	Get(string) string
+
+	// Has returns true if the variable exists, even if its value
+	// is empty
+	Has(string) bool
+
+	Int(string) (int, error)
+	Int64(string) (int64, error)
+	Uint(string) (uint, error)
+	Bool(string) (bool, error)
+
+	// UUID parses the value in the canonical 8-4-4-4-12 form.
+	// The result can be converted to UUID types provided by other
+	// libraries, which are usually defined as `[16]byte`
+	UUID(string) ([16]byte, error)
+
+	// Time parses the value using the given layout, as in `time.Parse`
+	Time(string, string) (time.Time, error)
 }
 
 // Vars returns the variable path components matched during
@@ -24,27 +56,236 @@ func Vars(req *http.Request) Values {
 	v := req.Context().Value(identMatchValues{})
 
 	switch v := v.(type) {
-	case Values:
-		return v
+	case pathmatch.Values:
+		return values(v)
 	default:
-		return pathmatch.Values{}
+		return values{}
 	}
 }
 
+// RegisterType registers a type that can be used in patterns of the form
+// `{name:type}`. `fn` is called with the value of a path segment, and
+// must report whether the value is valid for the type. The name must be
+// an identifier (e.g. `slug`), and may not already be registered.
+//
+// Types must be registered before patterns that refer to them are used.
+func RegisterType(name string, fn func(string) bool) error {
+	return pathmatch.RegisterType(name, fn)
+}
+
+// AllowedMethods returns the HTTP methods that are allowed for the
+// path of the request. It is only available from within the handler
+// assigned to `Router.MethodNotAllowed`, and returns nil otherwise.
+func AllowedMethods(req *http.Request) []string {
+	v, _ := req.Context().Value(identAllowedMethods{}).([]string)
+	return v
+}
+
 type path struct {
-	method  string
-	matcher *pathmatch.Matcher
-	handler http.Handler
+	method      string
+	pattern     string
+	matcher     *pathmatch.Matcher
+	handler     http.Handler
+	mount       bool
+	group       *Group
+	middlewares []Middleware
+	name        string
+	hostPattern string
+	host        *pathmatch.Matcher
+	predicates  []predicate
+	priority    int
+
+	metadata []interface{}
+
+	// caseInsensitive is true if the route was registered using
+	// `mux.WithCaseInsensitive`, while foldCase is true if the route
+	// is matched regardless of case, which also depends on the Router
+	caseInsensitive bool
+	foldCase        bool
+
+	// info describes the route, and is shared by all requests that
+	// the route handles
+	info Route
+}
+
+// match matches the path against the route, and returns the variables
+// along with the portion of the path that was not consumed by a mount
+func (p *path) match(s string) (pathmatch.Values, string, error) {
+	if p.mount {
+		return p.matcher.MatchPrefix(s)
+	}
+	mv, err := p.matcher.Match(s)
+	return mv, "", err
+}
+
+// accepts returns true if the request satisfies all of the conditions
+// of the route other than the path and the HTTP method
+func (p *path) accepts(mr *matchRequest) bool {
+	if p.host != nil {
+		if _, err := p.host.Match(mr.host); err != nil {
+			return false
+		}
+	}
+	for _, pred := range p.predicates {
+		if !pred.match(mr, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// capture stores the variables captured by the conditions of the route
+// other than the path into `mv`. It must only be called after `accepts`
+// returned true for the same request
+func (p *path) capture(mr *matchRequest, mv pathmatch.Values) {
+	if p.host != nil {
+		hv, _ := p.host.Match(mr.host)
+		for k, v := range hv {
+			if _, ok := mv[k]; !ok {
+				mv[k] = v
+			}
+		}
+	}
+	for _, pred := range p.predicates {
+		pred.match(mr, mv)
+	}
+}
+
+// matchRequest holds the information about the request being dispatched
+// that is used to select the route
+type matchRequest struct {
+	req   *http.Request
+	host  string
+	query url.Values
+}
+
+// Query returns the parsed query string of the request. It is parsed
+// only once, regardless of the number of routes that inspect it
+func (mr *matchRequest) Query() url.Values {
+	if mr.query == nil {
+		mr.query = mr.req.URL.Query()
+	}
+	return mr.query
+}
+
+func newMatchRequest(req *http.Request) *matchRequest {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return &matchRequest{
+		req:  req,
+		host: strings.ToLower(host),
+	}
 }
 
 // Router is the component that allows users to dispatch requests based on
 // HTTP method and path, which may include variable components in the
 // form of `/foo/bar/{id}` or `/foo/bar/{id:^[0-9]$}`
 //
+// Routes are compiled into a prefix tree, so the cost of dispatching
+// a request grows with the depth of the path rather than with the number
+// of registered routes.
+//
+// When no route matches the path of the request, the router responds
+// with a 404 Not Found. When the path matches one or more routes but none
+// of them accept the HTTP method of the request, the router responds with
+// a 405 Method Not Allowed, and lists the acceptable methods in the
+// `Allow` header.
+//
+// When AutoOptions is enabled, OPTIONS requests for paths that match at
+// least one route are answered automatically, unless an OPTIONS route has
+// been registered explicitly for the path. The same applies to CORS
+// preflight requests when a CORSPolicy has been assigned to CORS, in
+// which case other OPTIONS requests are answered automatically as well,
+// since OPTIONS is listed among the allowed methods.
+//
+// When AutoHead is enabled, HEAD requests for which no HEAD route has
+// been registered are dispatched to the matching GET route. The body
+// written by the handler is discarded.
+//
+// Routes may be registered, removed, or replaced using `Router.Swap`
+// while the router is serving requests. Requests are dispatched using
+// an immutable snapshot of the routes, and never wait for such changes.
+//
 // The zero value is safe to be used, but may not be copied.
 type Router struct {
-	mu    sync.RWMutex
-	paths []*path
+	// NotFound is the handler that is called when no route matches the
+	// request. If unspecified, an empty 404 response is sent
+	NotFound http.Handler
+
+	// MethodNotAllowed is the handler that is called when the path of
+	// the request matched, but the method did not. The `Allow` header
+	// is already populated when this handler is called, and the list of
+	// allowed methods can be retrieved using `mux.AllowedMethods`.
+	// If unspecified, an empty 405 response is sent
+	MethodNotAllowed http.Handler
+
+	// AutoOptions enables automatic responses to OPTIONS requests.
+	// The response lists the methods registered for the path in the
+	// `Allow` header
+	AutoOptions bool
+
+	// AutoHead enables dispatching HEAD requests to routes registered
+	// for GET, if no route has been registered for HEAD explicitly
+	AutoHead bool
+
+	// CORS is the policy used to respond to cross-origin requests,
+	// including preflight requests. Setting it also enables automatic
+	// responses to OPTIONS requests, as with AutoOptions. If unspecified,
+	// no CORS headers are sent
+	CORS CORSPolicy
+
+	// Conflicts determines what happens when a route that conflicts with
+	// an existing route is registered. See `Router.Validate` for details
+	// on how conflicts are detected
+	Conflicts ConflictPolicy
+
+	// OnConflict is called for each conflict when Conflicts is set to
+	// ConflictWarn. If unspecified, conflicts are written to the standard
+	// logger
+	OnConflict func(*Conflict)
+
+	// Precedence determines which route handles a request when more than
+	// one route matches it. It must be set before any route is registered
+	Precedence Precedence
+
+	// CleanPath determines how requests are handled when their paths
+	// contain duplicate slashes, `.` or `..` segments, or a trailing slash
+	// that does not match any route. By default, such paths are matched
+	// as they are. Otherwise, duplicate slashes and `.` and `..` segments
+	// are always cleaned up, even if the path matches a route as it is
+	CleanPath CleanPathMode
+
+	// MatchEscapedPath enables matching routes against the escaped path
+	// of the request, so that encoded slashes (`%2F`) may appear within
+	// variables of the form `{name}`. The path is decoded one segment at
+	// a time, and the value of each variable is decoded separately.
+	// Regular expressions and types see encoded slashes and percent signs
+	// as `%2F` and `%25` respectively
+	MatchEscapedPath bool
+
+	// CaseInsensitive makes the literal components of all path patterns
+	// match regardless of case. Variables capture the path as it is. It
+	// must be set before any route is registered. Individual routes may
+	// be made case-insensitive using `mux.WithCaseInsensitive`
+	CaseInsensitive bool
+
+	// NormalizeUnicode applies Unicode normalization form C (NFC) to the
+	// path of the request before it is matched, and to path patterns when
+	// they are registered, so that paths that are canonically equivalent
+	// match the same routes. Variables capture the normalized path. It
+	// must be set before any route is registered
+	NormalizeUnicode bool
+
+	// mu serializes changes to the routes and middlewares. Requests are
+	// dispatched using the table, and never take the lock
+	mu          sync.Mutex
+	table       atomic.Value // *table
+	middlewares []Middleware
 }
 
 // Handler is the generic way to associate an http.Handler to
@@ -66,115 +307,320 @@ type Router struct {
 // are captured.
 //
 // `/foo/bar/{id}` matches `/foo/bar/123` or `/foo/bar/%31%32%33` but not `/foo/bar/123/`
+// `/foo/bar/{id}` matches `/foo/bar/a%2Fb` with id `a/b` if `Router.MatchEscapedPath` is enabled
 // `/foo/bar/{id}/view` matches `/foo/bar/123/view` but not `/foo/bar//view`.
 //
+// When the form `{name:type}` is used, where type is an identifier such
+// as `int`, exactly one path segment is captured, and its value must
+// satisfy the named type. The following types are available by default,
+// and more can be added using `mux.RegisterType`:
+//
+//	int    optionally signed decimal integer
+//	uint   unsigned decimal integer
+//	uuid   UUID in the canonical 8-4-4-4-12 form
+//	alpha  ASCII letters
+//	alnum  ASCII letters and digits
+//	hex    hexadecimal digits
+//	date   date in the form YYYY-MM-DD
+//
+// `/foo/bar/{id:int}` matches `/foo/bar/123` but not `/foo/bar/123abc`
+//
 // Wehn the form `{name:regexp}` is used, the regular expression is matched
 // against all remaining segments of the path, including slashes.
+// Note that a regular expression that consists only of an identifier
+// is treated as a type name. Use `{name:(abc)}` to match the literal
+// text `abc` as a regular expression.
 //
 // `/foo/bar/{id:^[0-9]+}` matches `/foo/bar/123abc` but not `/foo/bar/abc123`
 // `/foo/bar/{id:[0-9]+$}` matches `/foo/bar/abc123` but not `/foo/bar/123abc`
 // `/foo/bar/{id:^[0-9]+$}` matches `/foo/bar/123` but not `/foo/bar/abc`
 // `/foo/bar/{rest:.*$}` matches anything under `/foo/bar/`
 //
+// When the form `{name...}` is used, the remainder of the path is
+// captured, including slashes. The captured value may be empty. Such
+// wildcards may only appear at the end of the pattern, and are the
+// preferred way to capture the rest of the path.
+//
+// `/files/{path...}` matches `/files/` and `/files/css/main.css`, but not `/files`
+//
+// A segment may contain more than one variable, as long as the variables
+// are separated by literals. Within such a segment, each variable captures
+// as little as possible while still allowing the rest of the segment to
+// match, and regular expressions must match the entire value of the
+// variable. Patterns where two variables are adjacent are rejected.
+//
+// `/files/{name}.{ext}` matches `/files/report.pdf` with name `report` and ext `pdf`
+// `/v{major:int}.{minor:int}/users` matches `/v1.2/users`
+//
+// Parts of the path may be made optional by enclosing them in square
+// brackets. Variables in an optional section that is absent from the
+// request are absent from `mux.Vars`. A variable may also be marked as
+// optional using `{name?}`, or given a default value using `{name=value}`,
+// which must satisfy the constraints of the variable.
+// If such a variable directly follows a slash, the slash is optional
+// as well. Patterns with more than 64 combinations of optional sections
+// being present or absent are rejected.
+//
+// `/posts[/{page}]` matches `/posts` and `/posts/2`
+// `/posts/{page:int?}` matches `/posts` and `/posts/2`
+// `/report/{format=json}` matches `/report` with format set to `json`
+//
+// Literal components are matched case-sensitively, unless
+// `Router.CaseInsensitive` is set, or the route is registered with
+// `mux.WithCaseInsensitive`.
+//
+// When more than one route matches a request, the route that was
+// registered first wins, unless `Router.Precedence` is set to
+// PrecedenceSpecificity, or the routes were given different priorities
+// using `mux.WithPriority`. Routes that can never be reached because of
+// this rule can be detected using `Router.Conflicts` or `Router.Validate`.
+//
+// The behavior of the route may be further customized by passing
+// RouteOptions, such as `mux.WithMiddleware`.
+func (r *Router) Handler(method string, pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.add(&path{method: method, handler: hh}, pattern, options)
+}
+
+func (r *Router) add(p *path, pattern string, options []RouteOption) error {
+	m, err := r.parse(pattern)
+	if err != nil {
+		return err
+	}
+	p.matcher = m
+	p.pattern = pattern
+
+	for _, option := range options {
+		if err := option.configure(p); err != nil {
+			return fmt.Errorf(`failed to apply route option: %w`, err)
+		}
+	}
+	r.prepare(p)
+
+	// conflicts are reported after the lock is released, so that
+	// OnConflict may call methods on the Router
+	var conflicts []*Conflict
+	defer func() { r.reportConflicts(conflicts) }()
 
-func (r *Router) Handler(method string, pattern string, hh http.Handler) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	t := r.load()
+	switch r.Conflicts {
+	case ConflictWarn:
+		conflicts = r.conflicts(t, p)
+	case ConflictError:
+		if found := r.conflicts(t, p); len(found) > 0 {
+			return found[0]
+		}
+	}
+
+	if name := p.name; name != "" {
+		if _, ok := t.names[name]; ok {
+			return fmt.Errorf(`route named %q already exists`, name)
+		}
+	}
+
+	r.publishAdded(p)
+	return nil
+}
+
+// parse parses the path pattern of a route according to the settings
+// of the Router
+func (r *Router) parse(pattern string) (*pathmatch.Matcher, error) {
+	if r.NormalizeUnicode {
+		pattern = norm.NFC.String(pattern)
+	}
 	m, err := pathmatch.Parse(pattern)
 	if err != nil {
-		return fmt.Errorf(`failed to parse path pattern: %w`, err)
+		return nil, fmt.Errorf(`failed to parse path pattern: %w`, err)
 	}
+	return m, nil
+}
 
-	r.paths = append(r.paths, &path{
-		method:  method,
-		matcher: m,
-		handler: hh,
-	})
-	return nil
+// prepare applies the settings of the Router to a route whose pattern
+// has just been parsed, once its options have been applied
+func (r *Router) prepare(p *path) {
+	p.foldCase = r.CaseInsensitive || p.caseInsensitive
+	if p.foldCase {
+		p.matcher.FoldCase()
+	}
+	p.info = p.route()
 }
 
 // Any declares an endpoint that responds to HTTP requests with
 // any HTTP verbs in the specified path pattern
-func (r *Router) Any(pattern string, hh http.Handler) error {
-	return r.Handler("", pattern, hh)
+func (r *Router) Any(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler("", pattern, hh, options...)
 }
 
 // Get declares an endpoint that responds to HTTP GET requests
 // in the specified path pattern
-func (r *Router) Get(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodGet, pattern, hh)
+func (r *Router) Get(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodGet, pattern, hh, options...)
 }
 
 // Head declares an endpoint that responds to HTTP HEAD requests
 // in the specified path pattern
-func (r *Router) Head(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodHead, pattern, hh)
+func (r *Router) Head(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodHead, pattern, hh, options...)
 }
 
 // Post declares an endpoint that responds to HTTP POST requests
 // in the specified path pattern
-func (r *Router) Post(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodPost, pattern, hh)
+func (r *Router) Post(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodPost, pattern, hh, options...)
 }
 
 // Put declares an endpoint that responds to HTTP PUT requests
 // in the specified path pattern
-func (r *Router) Put(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodPut, pattern, hh)
+func (r *Router) Put(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodPut, pattern, hh, options...)
 }
 
 // Patch declares an endpoint that responds to HTTP PATCH requests
 // in the specified path pattern
-func (r *Router) Patch(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodPatch, pattern, hh)
+func (r *Router) Patch(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodPatch, pattern, hh, options...)
 }
 
 // Delete declares an endpoint that responds to HTTP DELETE requests
 // in the specified path pattern
-func (r *Router) Delete(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodDelete, pattern, hh)
+func (r *Router) Delete(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodDelete, pattern, hh, options...)
 }
 
 // Connect declares an endpoint that responds to HTTP CONNECT requests
 // in the specified path pattern
-func (r *Router) Connect(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodConnect, pattern, hh)
+func (r *Router) Connect(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodConnect, pattern, hh, options...)
 }
 
 // Options declares an endpoint that responds to HTTP OPTIONS requests
 // in the specified path pattern
-func (r *Router) Options(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodOptions, pattern, hh)
+func (r *Router) Options(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodOptions, pattern, hh, options...)
 }
 
 // Trace declares an endpoint that responds to HTTP TRACE requests
 // in the specified path pattern
-func (r *Router) Trace(pattern string, hh http.Handler) error {
-	return r.Handler(http.MethodTrace, pattern, hh)
+func (r *Router) Trace(pattern string, hh http.Handler, options ...RouteOption) error {
+	return r.Handler(http.MethodTrace, pattern, hh, options...)
 }
 
 // ServeHTTP implements the http.Handler interface, allowing `*Router`
 // to be passed to anythign that expects an http.Handler
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	for _, path := range r.paths {
-		if method := path.method; method != "" {
-			if req.Method != method {
-				continue
+	t := r.load()
+	req, redirected := r.cleanRequest(t, w, req)
+	if redirected {
+		return
+	}
+
+	if len(t.ranked) > 0 {
+		mr := newMatchRequest(req)
+		p := r.matchPath(req)
+		key := t.tree.Lookup(p, func(key int) bool {
+			path := t.ranked[t.lookup(key)]
+			return (path.method == "" || path.method == req.Method) && path.accepts(mr)
+		})
+		var hw *headResponseWriter
+		if key < 0 && r.AutoHead && req.Method == http.MethodHead {
+			key = t.tree.Lookup(p, func(key int) bool {
+				path := t.ranked[t.lookup(key)]
+				return path.method == http.MethodGet && path.accepts(mr)
+			})
+			hw = &headResponseWriter{ResponseWriter: w}
+		}
+		if key >= 0 {
+			idx := t.lookup(key)
+			path := t.ranked[idx]
+			mv, rest, err := path.match(p)
+			if err == nil {
+				if r.MatchEscapedPath {
+					for k, v := range mv {
+						mv[k] = r.unescapeMatchPath(v)
+					}
+				}
+				path.capture(mr, mv)
+				if r.CORS != nil && req.Header.Get(`Origin`) != "" {
+					r.CORS.Apply(w, req)
+				}
+				// variables captured by the parent router of a mount are
+				// visible from the mounted router
+				if parent, ok := req.Context().Value(identMatchValues{}).(pathmatch.Values); ok {
+					for k, v := range parent {
+						if _, ok := mv[k]; !ok {
+							mv[k] = v
+						}
+					}
+				}
+				if path.mount {
+					req = r.stripPrefix(req, p, rest)
+				}
+				ctx := context.WithValue(req.Context(), identMatchValues{}, mv)
+				ctx = context.WithValue(ctx, identRoute{}, path)
+				hh := t.handlers[idx]
+				if hw != nil {
+					hh.ServeHTTP(hw, req.WithContext(ctx))
+					hw.finish()
+				} else {
+					hh.ServeHTTP(w, req.WithContext(ctx))
+				}
+				return
 			}
 		}
 
-		mv, err := path.matcher.Match(req.URL.Path)
-		if err != nil {
-			continue
+		if allowed := r.allowedMethods(t, mr, p); len(allowed) > 0 {
+			w.Header().Set(`Allow`, strings.Join(allowed, `, `))
+			if r.CORS != nil && isPreflight(req) {
+				r.CORS.Preflight(w, req, allowed)
+				return
+			}
+			// OPTIONS is allowed whenever it is listed in `Allow`
+			if (r.AutoOptions || r.CORS != nil) && req.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if hh := r.MethodNotAllowed; hh != nil {
+				ctx := context.WithValue(req.Context(), identAllowedMethods{}, allowed)
+				hh.ServeHTTP(w, req.WithContext(ctx))
+			} else {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
 		}
+	}
 
-		ctx := context.WithValue(req.Context(), identMatchValues{}, mv)
-		path.handler.ServeHTTP(w, req.WithContext(ctx))
-		return
+	if hh := r.NotFound; hh != nil {
+		hh.ServeHTTP(w, req)
+	} else {
+		w.WriteHeader(http.StatusNotFound)
 	}
+}
 
-	w.WriteHeader(http.StatusNotFound)
+// allowedMethods returns the sorted list of HTTP methods that have been
+// registered for routes matching the request, regardless of its method.
+func (r *Router) allowedMethods(t *table, mr *matchRequest, p string) []string {
+	seen := make(map[string]struct{})
+	t.tree.Lookup(p, func(key int) bool {
+		if path := t.ranked[t.lookup(key)]; path.method != "" && path.accepts(mr) {
+			seen[path.method] = struct{}{}
+		}
+		// keep looking for more matching routes
+		return false
+	})
+
+	if len(seen) > 0 && (r.AutoOptions || r.CORS != nil) {
+		seen[http.MethodOptions] = struct{}{}
+	}
+	if _, ok := seen[http.MethodGet]; ok && r.AutoHead {
+		seen[http.MethodHead] = struct{}{}
+	}
+
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
 }
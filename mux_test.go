@@ -1,6 +1,7 @@
 package mux_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -54,7 +55,7 @@ func TestMux(t *testing.T) {
 				{
 					Method: http.MethodHead,
 					Path:   `/foo/bar/baz/abcdef`,
-					Status: http.StatusNotFound,
+					Status: http.StatusMethodNotAllowed,
 				},
 				{
 					Method: http.MethodGet,
@@ -99,7 +100,7 @@ func TestMux(t *testing.T) {
 				{
 					Method: http.MethodHead,
 					Path:   `/foo/bar/baz/abcdef/view`,
-					Status: http.StatusNotFound,
+					Status: http.StatusMethodNotAllowed,
 				},
 				{
 					Method: http.MethodGet,
@@ -178,3 +179,525 @@ func TestMux(t *testing.T) {
 		})
 	}
 }
+
+func TestPrecedence(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, name)
+		})
+	}
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/users/{id}`, handler(`user`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/me`, handler(`me`)), `r.Get should succeed`)
+	require.NoError(t, r.Post(`/users/me`, handler(`post me`)), `r.Post should succeed`)
+	require.NoError(t, r.Get(`/users/{id}/posts`, handler(`posts`)), `r.Get should succeed`)
+
+	testcases := []struct {
+		Method   string
+		Path     string
+		Expected string
+	}{
+		{Method: http.MethodGet, Path: `/users/me`, Expected: `user`},
+		{Method: http.MethodPost, Path: `/users/me`, Expected: `post me`},
+		{Method: http.MethodGet, Path: `/users/123/posts`, Expected: `posts`},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(fmt.Sprintf("%s %s", tc.Method, tc.Path), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+			require.Equal(t, http.StatusOK, w.Code, `status code should match`)
+			require.Equal(t, tc.Expected, w.Body.String(), `first registered route should win`)
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	t.Run("default handlers", func(t *testing.T) {
+		var r mux.Router
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Delete(`/users/{id}`, noop), `r.Delete should succeed`)
+		require.NoError(t, r.Put(`/users/{id:^[0-9]+$}`, noop), `r.Put should succeed`)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/users/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `DELETE, GET, PUT`, w.Header().Get(`Allow`), `Allow header should match`)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/users/abc`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `DELETE, GET`, w.Header().Get(`Allow`), `Allow header should match`)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/groups/123`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `status code should be 404`)
+		require.Empty(t, w.Header().Get(`Allow`), `Allow header should not be set`)
+	})
+	t.Run("zero value", func(t *testing.T) {
+		var r mux.Router
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `status code should be 404`)
+		require.Empty(t, w.Body.String(), `body should be empty`)
+	})
+	t.Run("custom handlers", func(t *testing.T) {
+		r := mux.Router{
+			NotFound: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `not found`)
+			}),
+			MethodNotAllowed: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set(`Content-Type`, `application/json`)
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					`error`:   `method not allowed`,
+					`allowed`: mux.AllowedMethods(req),
+				})
+			}),
+		}
+		require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+		require.NoError(t, r.Put(`/users/{id}`, noop), `r.Put should succeed`)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/users/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.JSONEq(t, `{"error":"method not allowed","allowed":["GET","PUT"]}`, w.Body.String(), `body should match`)
+		require.Equal(t, `GET, PUT`, w.Header().Get(`Allow`), `Allow header should match`)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/groups/123`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `status code should be 404`)
+		require.Equal(t, `not found`, w.Body.String(), `body should match`)
+	})
+}
+
+func TestAutoOptions(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	r := mux.Router{AutoOptions: true}
+	require.NoError(t, r.Get(`/users/{id}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Delete(`/users/{id}`, noop), `r.Delete should succeed`)
+	require.NoError(t, r.Get(`/groups/{id}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Options(`/groups/{id}`, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `explicit`)
+	})), `r.Options should succeed`)
+
+	t.Run("automatic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, `/users/123`, nil))
+		require.Equal(t, http.StatusNoContent, w.Code, `status code should be 204`)
+		require.Equal(t, `DELETE, GET, OPTIONS`, w.Header().Get(`Allow`), `Allow header should match`)
+	})
+	t.Run("explicit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, `/groups/123`, nil))
+		require.Equal(t, http.StatusOK, w.Code, `status code should be 200`)
+		require.Equal(t, `explicit`, w.Body.String(), `explicit handler should be called`)
+	})
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, `/posts/123`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `status code should be 404`)
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/users/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `DELETE, GET, OPTIONS`, w.Header().Get(`Allow`), `Allow header should match`)
+	})
+}
+
+func TestAutoHead(t *testing.T) {
+	r := mux.Router{AutoHead: true}
+	require.NoError(t, r.Get(`/users/{id}`, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(`Content-Type`, `text/plain`)
+		fmt.Fprint(w, `hello, world`)
+	})), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/groups/{id}`, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `hello, world`)
+	})), `r.Get should succeed`)
+	require.NoError(t, r.Head(`/groups/{id}`, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(`X-Explicit`, `true`)
+	})), `r.Head should succeed`)
+	require.NoError(t, r.Post(`/posts/{id}`, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})), `r.Post should succeed`)
+
+	var unwrapped http.ResponseWriter
+	require.NoError(t, r.Get(`/stream`, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+			unwrapped = u.Unwrap()
+		}
+		w.Header().Set(`X-Stream`, `true`)
+		fmt.Fprint(w, `hello, `)
+		w.(http.Flusher).Flush()
+		w.Header().Set(`X-Late`, `true`)
+		fmt.Fprint(w, `world`)
+		w.(http.Flusher).Flush()
+	})), `r.Get should succeed`)
+
+	t.Run("fallback to GET", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, `/users/123`, nil))
+		require.Equal(t, http.StatusOK, w.Code, `status code should be 200`)
+		require.Empty(t, w.Body.String(), `body should be discarded`)
+		require.Equal(t, `12`, w.Header().Get(`Content-Length`), `Content-Length should be preserved`)
+		require.Equal(t, `text/plain`, w.Header().Get(`Content-Type`), `headers should be preserved`)
+	})
+	t.Run("explicit HEAD", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, `/groups/123`, nil))
+		require.Equal(t, http.StatusOK, w.Code, `status code should be 200`)
+		require.Equal(t, `true`, w.Header().Get(`X-Explicit`), `explicit handler should be called`)
+	})
+	t.Run("flush", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, `/stream`, nil))
+		require.Equal(t, http.StatusOK, w.Code, `status code should be 200`)
+		require.True(t, w.Flushed, `response should be flushed`)
+		require.Empty(t, w.Body.String(), `body should be discarded`)
+		require.Equal(t, `true`, w.Result().Header.Get(`X-Stream`), `headers should be sent when flushing`)
+		require.Empty(t, w.Result().Header.Get(`X-Late`), `headers set after flushing should not be sent`)
+		require.Empty(t, w.Result().Header.Get(`Content-Length`), `Content-Length should not be guessed after flushing`)
+		require.Same(t, w, unwrapped, `Unwrap should return the underlying http.ResponseWriter`)
+	})
+	t.Run("no GET route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, `/posts/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `POST`, w.Header().Get(`Allow`), `Allow header should match`)
+	})
+	t.Run("Allow header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, `/users/123`, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, `status code should be 405`)
+		require.Equal(t, `GET, HEAD`, w.Header().Get(`Allow`), `Allow header should match`)
+	})
+}
+
+func TestTypedVariables(t *testing.T) {
+	require.NoError(t, mux.RegisterType(`slug`, func(s string) bool {
+		for _, r := range s {
+			if !(r >= 'a' && r <= 'z') && r != '-' {
+				return false
+			}
+		}
+		return s != ""
+	}), `mux.RegisterType should succeed`)
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, mux.Vars(r).Get(`id`))
+	})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/posts/{id:int}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/posts/{id:slug}`, echo), `r.Get should succeed`)
+	require.Error(t, r.Get(`/posts/{id:unknown}`, echo), `unknown types should be rejected`)
+
+	testcases := []struct {
+		Path   string
+		Status int
+	}{
+		{Path: `/posts/123`, Status: http.StatusOK},
+		{Path: `/posts/hello-world`, Status: http.StatusOK},
+		{Path: `/posts/123abc`, Status: http.StatusNotFound},
+		{Path: `/posts/123/comments`, Status: http.StatusNotFound},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+		})
+	}
+}
+
+func TestOptionalSegments(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		fmt.Fprintf(w, "%t:%s:%s", vars.Has(`page`), vars.Get(`page`), vars.Get(`format`))
+	})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/posts[/{page:int}]`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/report/{format=json}`, echo), `r.Get should succeed`)
+	require.Error(t, r.Get(`/broken[/{page}`, echo), `unbalanced brackets should be rejected`)
+
+	testcases := []struct {
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{Path: `/posts`, Status: http.StatusOK, Expected: `false::`},
+		{Path: `/posts/2`, Status: http.StatusOK, Expected: `true:2:`},
+		{Path: `/posts/abc`, Status: http.StatusNotFound},
+		{Path: `/report`, Status: http.StatusOK, Expected: `false::json`},
+		{Path: `/report/csv`, Status: http.StatusOK, Expected: `false::csv`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Status == http.StatusOK {
+				require.Equal(t, tc.Expected, w.Body.String(), `variables should match`)
+			}
+		})
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, mux.Vars(r).Get(`path`))
+	})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/files/{path...}`, echo), `r.Get should succeed`)
+	require.Error(t, r.Get(`/files/{path...}/view`, echo), `wildcards in the middle of the pattern should be rejected`)
+
+	testcases := []struct {
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{Path: `/files/css/main.css`, Status: http.StatusOK, Expected: `css/main.css`},
+		{Path: `/files/`, Status: http.StatusOK, Expected: ``},
+		{Path: `/files`, Status: http.StatusNotFound},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Status == http.StatusOK {
+				require.Equal(t, tc.Expected, w.Body.String(), `captured value should match`)
+			}
+		})
+	}
+}
+
+func TestCompoundSegments(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		fmt.Fprintf(w, "%s|%s", vars.Get(`name`), vars.Get(`ext`))
+	})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/files/{name}.{ext}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/files/{name}`, echo), `r.Get should succeed`)
+	require.Error(t, r.Get(`/files/{name}{ext}`, echo), `adjacent variables should be rejected`)
+
+	testcases := []struct {
+		Path     string
+		Expected string
+	}{
+		{Path: `/files/report.pdf`, Expected: `report|pdf`},
+		{Path: `/files/archive.tar.gz`, Expected: `archive|tar.gz`},
+		{Path: `/files/README`, Expected: `README|`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, http.StatusOK, w.Code, `status code should match`)
+			require.Equal(t, tc.Expected, w.Body.String(), `variables should match`)
+		})
+	}
+}
+
+func TestSpecificity(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, name)
+		})
+	}
+
+	r := mux.Router{Precedence: mux.PrecedenceSpecificity}
+	require.NoError(t, r.Mount(`/users`, handler(`mount`)), `r.Mount should succeed`)
+	require.NoError(t, r.Get(`/users/{path...}`, handler(`wildcard`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id}`, handler(`plain`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id:int}`, handler(`int`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{name}.json`, handler(`mixed`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/me`, handler(`me`)), `r.Get should succeed`)
+	require.NoError(t, r.Any(`/users/admin`, handler(`any admin`)), `r.Any should succeed`)
+	require.NoError(t, r.Get(`/users/admin`, handler(`get admin`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id}/posts`, handler(`low`), mux.WithPriority(-1)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id}/{tab}`, handler(`tab`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id}/{tab}`, handler(`priority`), mux.WithPriority(1), mux.MatchQueryPresent(`p`)), `r.Get should succeed`)
+
+	testcases := []struct {
+		Method   string
+		Path     string
+		Expected string
+	}{
+		{Method: http.MethodGet, Path: `/users/me`, Expected: `me`},
+		{Method: http.MethodGet, Path: `/users/123`, Expected: `int`},
+		{Method: http.MethodGet, Path: `/users/john.json`, Expected: `mixed`},
+		{Method: http.MethodGet, Path: `/users/john`, Expected: `plain`},
+		{Method: http.MethodGet, Path: `/users/john/posts/1`, Expected: `wildcard`},
+		{Method: http.MethodPost, Path: `/users/john`, Expected: `mount`},
+		{Method: http.MethodGet, Path: `/users/admin`, Expected: `get admin`},
+		{Method: http.MethodPost, Path: `/users/admin`, Expected: `any admin`},
+		{Method: http.MethodGet, Path: `/users/john/posts`, Expected: `tab`},
+		{Method: http.MethodGet, Path: `/users/john/posts?p`, Expected: `priority`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(fmt.Sprintf("%s %s", tc.Method, tc.Path), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+			require.Equal(t, http.StatusOK, w.Code, `status code should match`)
+			require.Equal(t, tc.Expected, w.Body.String(), `most specific route should win`)
+		})
+	}
+
+	t.Run("registration order", func(t *testing.T) {
+		var r mux.Router
+		require.NoError(t, r.Get(`/users/{id}`, handler(`plain`)), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/users/me`, handler(`me`), mux.WithPriority(1)), `r.Get should succeed`)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/users/me`, nil))
+		require.Equal(t, `me`, w.Body.String(), `routes with higher priority should win`)
+
+		routes := r.Routes()
+		require.Equal(t, `/users/{id}`, routes[0].Pattern, `r.Routes should return routes in order of registration`)
+		require.Equal(t, 1, routes[1].Priority, `priority should be reported`)
+	})
+}
+
+func TestMatchEscapedPath(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		fmt.Fprintf(w, `%s name=%s path=%s`, r.URL.EscapedPath(), vars.Get(`name`), vars.Get(`path`))
+	})
+
+	var inner mux.Router
+	inner.MatchEscapedPath = true
+	require.NoError(t, inner.Get(`/{name}`, echo), `inner.Get should succeed`)
+
+	var r mux.Router
+	r.MatchEscapedPath = true
+	require.NoError(t, r.Get(`/files/{name}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/ids/{name:^[0-9]+$}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/café/{name}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/raw/{path...}`, echo), `r.Get should succeed`)
+	require.NoError(t, r.Mount(`/mounted/{name}`, &inner), `r.Mount should succeed`)
+
+	testcases := []struct {
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{Path: `/files/report`, Status: http.StatusOK, Expected: `/files/report name=report path=`},
+		{Path: `/files/a%2Fb`, Status: http.StatusOK, Expected: `/files/a%2Fb name=a/b path=`},
+		{Path: `/files/100%25`, Status: http.StatusOK, Expected: `/files/100%25 name=100% path=`},
+		{Path: `/files/a%252Fb`, Status: http.StatusOK, Expected: `/files/a%252Fb name=a%2Fb path=`},
+		{Path: `/files/a/b`, Status: http.StatusNotFound},
+		{Path: `/ids/%31%32%33`, Status: http.StatusOK, Expected: `/ids/%31%32%33 name=123 path=`},
+		{Path: `/ids/1%2F2`, Status: http.StatusNotFound},
+		{Path: `/caf%C3%A9/a%2Fb`, Status: http.StatusOK, Expected: `/caf%C3%A9/a%2Fb name=a/b path=`},
+		{Path: `/raw/a%2Fb/c`, Status: http.StatusOK, Expected: `/raw/a%2Fb/c name= path=a/b/c`},
+		{Path: `/mounted/x%2Fy/a%2Fb`, Status: http.StatusOK, Expected: `/a%2Fb name=a/b path=`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var r mux.Router
+		require.NoError(t, r.Get(`/files/{name}`, echo), `r.Get should succeed`)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/files/a%2Fb`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `encoded slashes should separate segments`)
+	})
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `%s id=%s`, r.URL.Path, mux.Vars(r).Get(`id`))
+	})
+
+	t.Run("router", func(t *testing.T) {
+		r := mux.Router{CaseInsensitive: true}
+		require.NoError(t, r.Get(`/users/{id}`, echo), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/users/{id}/Posts`, echo), `r.Get should succeed`)
+
+		testcases := []struct {
+			Path     string
+			Status   int
+			Expected string
+		}{
+			{Path: `/users/abc`, Status: http.StatusOK, Expected: `/users/abc id=abc`},
+			{Path: `/Users/ABC`, Status: http.StatusOK, Expected: `/Users/ABC id=ABC`},
+			{Path: `/USERS/AbC/posts`, Status: http.StatusOK, Expected: `/USERS/AbC/posts id=AbC`},
+			{Path: `/members/abc`, Status: http.StatusNotFound},
+		}
+		for _, tc := range testcases {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.Path, nil))
+			require.Equal(t, tc.Status, w.Code, `status code for %s should match`, tc.Path)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body for %s should match`, tc.Path)
+			}
+		}
+
+		for _, route := range r.Routes() {
+			require.True(t, route.CaseInsensitive, `routes should be case-insensitive`)
+		}
+	})
+
+	t.Run("route", func(t *testing.T) {
+		var r mux.Router
+		require.NoError(t, r.Get(`/users/{id}`, echo, mux.WithCaseInsensitive()), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/posts/{id}`, echo), `r.Get should succeed`)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/Users/ABC`, nil))
+		require.Equal(t, http.StatusOK, w.Code, `case-insensitive route should match`)
+		require.Equal(t, `/Users/ABC id=ABC`, w.Body.String(), `variables should keep their case`)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/Posts/ABC`, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, `other routes should remain case-sensitive`)
+	})
+
+	t.Run("unicode normalization", func(t *testing.T) {
+		// the pattern is in NFD form, where é is e followed by a combining accent
+		r := mux.Router{NormalizeUnicode: true, CaseInsensitive: true}
+		require.NoError(t, r.Get("/cafe\u0301/{id}", echo), `r.Get should succeed`)
+
+		for _, path := range []string{"/caf\u00e9/1", "/cafe\u0301/1", "/CAF\u00c9/1", "/CAFE\u0301/1"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, `/`, nil)
+			req.URL.Path = path
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, `%q should match`, path)
+		}
+
+		var strict mux.Router
+		require.NoError(t, strict.Get("/cafe\u0301/{id}", echo), `strict.Get should succeed`)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, `/`, nil)
+		req.URL.Path = "/caf\u00e9/1"
+		strict.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code, `paths should not be normalized by default`)
+	})
+}
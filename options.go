@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+// RouteOption is used to customize a route when it is registered
+// through methods such as `Router.Handler` or `Router.Get`.
+type RouteOption interface {
+	configure(*path) error
+}
+
+type routeOptionFunc func(*path) error
+
+func (f routeOptionFunc) configure(p *path) error {
+	return f(p)
+}
+
+// WithMiddleware specifies middlewares that only apply to the route
+// being registered. They are invoked after the middlewares of the
+// Router and of the Group that the route belongs to.
+func WithMiddleware(middlewares ...Middleware) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		p.middlewares = append(p.middlewares, middlewares...)
+		return nil
+	})
+}
+
+// WithName assigns a name to the route being registered. Names must be
+// unique within a Router, and can be used to generate URLs for the route
+// using `Router.URL`.
+func WithName(name string) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		p.name = name
+		return nil
+	})
+}
+
+// WithHost restricts the route to requests whose host matches the
+// pattern. The host of the request is compared without its port, and
+// in lower case.
+//
+// The pattern uses the same syntax as path patterns, except that the
+// form `{name}` matches a single label delimited by dots (`.`), as in
+// `{tenant}.example.com`. The variables captured from the host are
+// available through `mux.Vars`, and may not share names with the
+// variables in the path pattern.
+func WithHost(pattern string) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		m, err := pathmatch.ParseHost(pattern)
+		if err != nil {
+			return fmt.Errorf(`failed to parse host pattern: %w`, err)
+		}
+
+		for _, name := range m.Variables() {
+			for _, other := range p.matcher.Variables() {
+				if name == other {
+					return fmt.Errorf(`variable %q is declared in both the host and path patterns`, name)
+				}
+			}
+		}
+
+		p.hostPattern = pattern
+		p.host = m
+		return nil
+	})
+}
+
+// WithCaseInsensitive makes the literal components of the path pattern
+// match regardless of case, so that `/users/{id}` matches `/Users/ABC`.
+// The values of variables keep the case that they were sent in. Use
+// `Router.CaseInsensitive` to apply this to every route of a Router.
+func WithCaseInsensitive() RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		p.caseInsensitive = true
+		return nil
+	})
+}
+
+// WithMetadata attaches values to the route being registered, such as
+// the scopes required to access it, or the ID of an OpenAPI operation.
+// Values are told apart by their types, and a value replaces any value
+// of the same type that was attached before. Defining a type for each
+// kind of metadata avoids collisions between packages:
+//
+//	type Scopes []string
+//	r.Get(`/users/{id}`, h, mux.WithMetadata(Scopes{`users:read`}))
+//
+// The values can be retrieved using `mux.RouteMetadata`, either from
+// `mux.CurrentRoute` while handling a request, or from `Router.Routes`.
+// They are shared by all requests, and must not be modified.
+func WithMetadata(values ...interface{}) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		for _, v := range values {
+			if v == nil {
+				return fmt.Errorf(`metadata may not be nil`)
+			}
+			p.metadata = setMetadata(p.metadata, v)
+		}
+		return nil
+	})
+}
+
+func setMetadata(metadata []interface{}, v interface{}) []interface{} {
+	typ := reflect.TypeOf(v)
+	for i, existing := range metadata {
+		if reflect.TypeOf(existing) == typ {
+			metadata[i] = v
+			return metadata
+		}
+	}
+	return append(metadata, v)
+}
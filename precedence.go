@@ -0,0 +1,69 @@
+package mux
+
+import "github.com/lestrrat-go/mux/internal/pathmatch"
+
+// Precedence determines which route handles a request when more than one
+// route matches it.
+type Precedence int
+
+const (
+	// PrecedenceOrder gives precedence to the route that was registered
+	// first. This is the default
+	PrecedenceOrder Precedence = iota
+
+	// PrecedenceSpecificity gives precedence to the route with the most
+	// specific pattern, regardless of the order of registration.
+	//
+	// Patterns are compared segment by segment, and literal segments are
+	// preferred over segments mixing literals and variables, which are
+	// preferred over typed and regular expression variables, which are
+	// preferred over plain `{name}` variables, which are preferred over
+	// wildcards. Mounts are ranked as if their prefix were followed by
+	// a wildcard.
+	//
+	// When two patterns are equally specific, routes for a specific
+	// method are preferred over routes for any method, then routes with
+	// more conditions (host and other matchers) are preferred, and the
+	// route that was registered first wins the remaining ties.
+	PrecedenceSpecificity
+)
+
+// WithPriority assigns a priority to the route being registered. Routes
+// with higher priorities take precedence over routes with lower
+// priorities, regardless of `Router.Precedence`. The default priority
+// is 0.
+func WithPriority(priority int) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		p.priority = priority
+		return nil
+	})
+}
+
+// precedes returns true if route `a` takes precedence over route `b`,
+// without taking the order of registration into account
+func (r *Router) precedes(a, b *path) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if r.Precedence != PrecedenceSpecificity {
+		return false
+	}
+
+	if c := pathmatch.CompareSpecificity(a.matcher, a.mount, b.matcher, b.mount); c != 0 {
+		return c < 0
+	}
+	if (a.method != "") != (b.method != "") {
+		return a.method != ""
+	}
+	return a.conditions() > b.conditions()
+}
+
+// conditions returns the number of conditions of the route other than
+// the path and the method
+func (p *path) conditions() int {
+	n := len(p.predicates)
+	if p.host != nil {
+		n++
+	}
+	return n
+}
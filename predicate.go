@@ -0,0 +1,187 @@
+package mux
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+// predicate is a condition on the request that must be satisfied for a
+// route to match, in addition to the path and the HTTP method.
+//
+// If `mv` is non-nil, any values captured by the predicate are stored
+// in it, without overwriting existing values.
+type predicate interface {
+	match(mr *matchRequest, mv pathmatch.Values) bool
+}
+
+type predicateFunc func(*matchRequest, pathmatch.Values) bool
+
+func (f predicateFunc) match(mr *matchRequest, mv pathmatch.Values) bool {
+	return f(mr, mv)
+}
+
+func withPredicate(pred predicate) RouteOption {
+	return routeOptionFunc(func(p *path) error {
+		p.predicates = append(p.predicates, pred)
+		return nil
+	})
+}
+
+// regexpPredicate matches a value extracted from the request against a
+// regular expression. Named subexpressions are captured as variables
+type regexpPredicate struct {
+	pattern *regexp.Regexp
+	value   func(*matchRequest) (string, bool)
+}
+
+func newRegexpPredicate(pattern string, value func(*matchRequest) (string, bool)) (RouteOption, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to compile pattern %q: %w`, pattern, err)
+	}
+	return withPredicate(&regexpPredicate{pattern: re, value: value}), nil
+}
+
+func (pred *regexpPredicate) match(mr *matchRequest, mv pathmatch.Values) bool {
+	v, ok := pred.value(mr)
+	if !ok {
+		return false
+	}
+
+	if mv == nil {
+		return pred.pattern.MatchString(v)
+	}
+
+	matches := pred.pattern.FindStringSubmatch(v)
+	if matches == nil {
+		return false
+	}
+	for i, name := range pred.pattern.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if _, ok := mv[name]; !ok {
+			mv[name] = matches[i]
+		}
+	}
+	return true
+}
+
+func errorOption(err error) RouteOption {
+	return routeOptionFunc(func(*path) error {
+		return err
+	})
+}
+
+func headerValue(name string) func(*matchRequest) (string, bool) {
+	return func(mr *matchRequest) (string, bool) {
+		values := mr.req.Header.Values(name)
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	}
+}
+
+func queryValue(name string) func(*matchRequest) (string, bool) {
+	return func(mr *matchRequest) (string, bool) {
+		values, ok := mr.Query()[name]
+		if !ok || len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	}
+}
+
+// MatchHeader restricts the route to requests where the header `name`
+// is equal to `value`.
+func MatchHeader(name, value string) RouteOption {
+	get := headerValue(name)
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		v, ok := get(mr)
+		return ok && v == value
+	}))
+}
+
+// MatchHeaderRegexp restricts the route to requests where the header
+// `name` matches the regular expression `pattern`. Values matched by
+// named subexpressions, such as `(?P<version>[0-9]+)`, are available
+// through `mux.Vars`.
+func MatchHeaderRegexp(name, pattern string) RouteOption {
+	option, err := newRegexpPredicate(pattern, headerValue(name))
+	if err != nil {
+		return errorOption(fmt.Errorf(`mux.MatchHeaderRegexp: %w`, err))
+	}
+	return option
+}
+
+// MatchQueryPresent restricts the route to requests where the query
+// parameter `name` is present, regardless of its value.
+func MatchQueryPresent(name string) RouteOption {
+	get := queryValue(name)
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		_, ok := get(mr)
+		return ok
+	}))
+}
+
+// MatchQuery restricts the route to requests where the query parameter
+// `name` is equal to `value`.
+func MatchQuery(name, value string) RouteOption {
+	get := queryValue(name)
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		v, ok := get(mr)
+		return ok && v == value
+	}))
+}
+
+// MatchQueryRegexp restricts the route to requests where the query
+// parameter `name` matches the regular expression `pattern`. Values
+// matched by named subexpressions are available through `mux.Vars`.
+func MatchQueryRegexp(name, pattern string) RouteOption {
+	option, err := newRegexpPredicate(pattern, queryValue(name))
+	if err != nil {
+		return errorOption(fmt.Errorf(`mux.MatchQueryRegexp: %w`, err))
+	}
+	return option
+}
+
+// MatchContentType restricts the route to requests whose `Content-Type`
+// header specifies one of the given media types. Parameters such as
+// `charset` are ignored, and the comparison is case-insensitive.
+func MatchContentType(mediaTypes ...string) RouteOption {
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		mediaType, _, err := mime.ParseMediaType(mr.req.Header.Get(`Content-Type`))
+		if err != nil {
+			return false
+		}
+		return containsFold(mediaTypes, mediaType)
+	}))
+}
+
+// MatchScheme restricts the route to requests made using one of the
+// given URL schemes, such as `https`. For requests received by a server,
+// the scheme is `https` if the connection uses TLS, and `http` otherwise.
+func MatchScheme(schemes ...string) RouteOption {
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		scheme := mr.req.URL.Scheme
+		if scheme == "" {
+			scheme = `http`
+			if mr.req.TLS != nil {
+				scheme = `https`
+			}
+		}
+		return containsFold(schemes, scheme)
+	}))
+}
+
+// MatchFunc restricts the route to requests for which `fn` returns true.
+func MatchFunc(fn func(*http.Request) bool) RouteOption {
+	return withPredicate(predicateFunc(func(mr *matchRequest, _ pathmatch.Values) bool {
+		return fn(mr.req)
+	}))
+}
@@ -0,0 +1,142 @@
+package mux_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicates(t *testing.T) {
+	echo := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			fmt.Fprintf(w, `%s id=%s version=%s page=%s`, name, vars.Get(`id`), vars.Get(`version`), vars.Get(`page`))
+		})
+	}
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/items/{id}`, echo(`v2`), mux.MatchHeaderRegexp(`Accept`, `^application/vnd\.example\.v(?P<version>[0-9]+)\+json$`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`debug`), mux.MatchHeader(`X-Debug`, `1`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`paged`), mux.MatchQueryRegexp(`page`, `^(?P<page>[0-9]+)$`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`archive`), mux.MatchQuery(`action`, `archive`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`flagged`), mux.MatchQueryPresent(`flag`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`secure`), mux.MatchScheme(`https`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`func`), mux.MatchFunc(func(req *http.Request) bool {
+		return req.Header.Get(`X-Func`) != ""
+	})), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/items/{id}`, echo(`default`)), `r.Get should succeed`)
+	require.NoError(t, r.Post(`/items/{id}`, echo(`json`), mux.MatchContentType(`application/json`)), `r.Post should succeed`)
+
+	require.Error(t, r.Get(`/invalid`, echo(`invalid`), mux.MatchQueryRegexp(`page`, `(`)), `invalid regular expressions should be rejected`)
+
+	testcases := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Header   http.Header
+		TLS      bool
+		Status   int
+		Expected string
+	}{
+		{
+			Name:     `header regexp`,
+			Target:   `/items/1`,
+			Header:   http.Header{`Accept`: {`application/vnd.example.v2+json`}},
+			Expected: `v2 id=1 version=2 page=`,
+		},
+		{
+			Name:     `header equals`,
+			Target:   `/items/1`,
+			Header:   http.Header{`X-Debug`: {`1`}},
+			Expected: `debug id=1 version= page=`,
+		},
+		{
+			Name:     `header does not equal`,
+			Target:   `/items/1`,
+			Header:   http.Header{`X-Debug`: {`0`}},
+			Expected: `default id=1 version= page=`,
+		},
+		{
+			Name:     `query regexp`,
+			Target:   `/items/1?page=3`,
+			Expected: `paged id=1 version= page=3`,
+		},
+		{
+			Name:     `query regexp does not match`,
+			Target:   `/items/1?page=abc`,
+			Expected: `default id=1 version= page=`,
+		},
+		{
+			Name:     `query equals`,
+			Target:   `/items/1?action=archive`,
+			Expected: `archive id=1 version= page=`,
+		},
+		{
+			Name:     `query present`,
+			Target:   `/items/1?flag`,
+			Expected: `flagged id=1 version= page=`,
+		},
+		{
+			Name:     `scheme`,
+			Target:   `/items/1`,
+			TLS:      true,
+			Expected: `secure id=1 version= page=`,
+		},
+		{
+			Name:     `func`,
+			Target:   `/items/1`,
+			Header:   http.Header{`X-Func`: {`yes`}},
+			Expected: `func id=1 version= page=`,
+		},
+		{
+			Name:     `content type`,
+			Method:   http.MethodPost,
+			Target:   `/items/1`,
+			Header:   http.Header{`Content-Type`: {`Application/JSON; charset=utf-8`}},
+			Expected: `json id=1 version= page=`,
+		},
+		{
+			Name:   `content type does not match`,
+			Method: http.MethodPost,
+			Target: `/items/1`,
+			Header: http.Header{`Content-Type`: {`text/plain`}},
+			Status: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			method := tc.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.Target, strings.NewReader(``))
+			for k, v := range tc.Header {
+				req.Header[k] = v
+			}
+			if tc.TLS {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			status := tc.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			require.Equal(t, status, w.Code, `status code should match`)
+			if tc.Expected != "" {
+				require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package mux
+
+import (
+	"net/http"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+// Route describes a route registered in a Router.
+type Route struct {
+	// Method is the HTTP method that the route responds to. It is
+	// empty if the route responds to any method
+	Method string
+
+	// Pattern is the path pattern of the route, including the prefix
+	// of the group that the route was registered through
+	Pattern string
+
+	// Name is the name assigned to the route using `mux.WithName`
+	Name string
+
+	// Host is the host pattern assigned to the route using `mux.WithHost`
+	Host string
+
+	// Vars describes the variable components of the pattern, in the
+	// order that they appear
+	Vars []RouteVar
+
+	// Mount is true if the route was registered using `Router.Mount`
+	Mount bool
+
+	// Priority is the priority assigned to the route using `mux.WithPriority`
+	Priority int
+
+	// CaseInsensitive is true if the literal components of the pattern
+	// are matched regardless of case
+	CaseInsensitive bool
+
+	// Metadata contains the values attached to the route using
+	// `mux.WithMetadata`, in the order that they were attached
+	Metadata []interface{}
+}
+
+// RouteVar describes a variable component of a path pattern.
+type RouteVar struct {
+	// Name is the name of the variable
+	Name string
+
+	// Type is the name of the type that the variable must satisfy, as in
+	// `{id:int}`. It is empty if the variable is not typed
+	Type string
+
+	// Constraint is the regular expression that the variable must match.
+	// It is empty if the variable is not constrained by a regular expression
+	Constraint string
+
+	// Wildcard is true if the variable captures the remainder of the
+	// path, as in `{path...}`
+	Wildcard bool
+
+	// Optional is true if the variable may be absent from the path,
+	// either because it is in an optional section, or because it was
+	// declared as `{name?}` or `{name=default}`
+	Optional bool
+
+	// Default is the value of the variable when it is absent from the
+	// path, as in `{name=default}`
+	Default string
+}
+
+// Routes returns the list of routes registered in the Router, in
+// the order that they were registered.
+func (r *Router) Routes() []Route {
+	t := r.load()
+	routes := make([]Route, 0, len(t.paths))
+	for _, p := range t.paths {
+		routes = append(routes, p.route())
+	}
+	return routes
+}
+
+// CurrentRoute returns the route that matched the request, which is
+// useful for labeling metrics and logs using the pattern of the route
+// instead of the path of the request. It is available to middlewares
+// and handlers of the route. When a Router is mounted in another Router,
+// the route of the innermost Router that matched the request is returned.
+//
+// The second return value is false if the request was not dispatched
+// by a Router. The Vars and Metadata of the returned Route are shared,
+// and must not be modified.
+func CurrentRoute(req *http.Request) (Route, bool) {
+	p, ok := req.Context().Value(identRoute{}).(*path)
+	if !ok {
+		return Route{}, false
+	}
+	return p.info, true
+}
+
+func (p *path) route() Route {
+	return Route{
+		Method:          p.method,
+		Pattern:         p.pattern,
+		Name:            p.name,
+		Host:            p.hostPattern,
+		Vars:            routeVars(p.matcher.Expressions(), false),
+		Mount:           p.mount,
+		Priority:        p.priority,
+		CaseInsensitive: p.foldCase,
+		Metadata:        copyMetadata(p.metadata),
+	}
+}
+
+func copyMetadata(metadata []interface{}) []interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make([]interface{}, len(metadata))
+	copy(result, metadata)
+	return result
+}
+
+// RouteMetadata returns the value of type T that was attached to the
+// route using `mux.WithMetadata`. If T is an interface type, the first
+// value that implements it is returned. The second return value is false
+// if no such value was attached.
+//
+//	route, _ := mux.CurrentRoute(req)
+//	scopes, ok := mux.RouteMetadata[Scopes](route)
+func RouteMetadata[T any](route Route) (T, bool) {
+	for _, v := range route.Metadata {
+		if v, ok := v.(T); ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func routeVars(exprs []pathmatch.Expression, optional bool) []RouteVar {
+	var vars []RouteVar
+	for _, expr := range exprs {
+		var v RouteVar
+		var def string
+		switch expr := expr.(type) {
+		case *pathmatch.Optional:
+			vars = append(vars, routeVars(expr.Exprs, true)...)
+			continue
+		case *pathmatch.LiteralPattern:
+			v = RouteVar{Name: expr.Name}
+			def = expr.Default
+		case *pathmatch.TypedPattern:
+			v = RouteVar{Name: expr.Name, Type: expr.Type}
+			def = expr.Default
+		case *pathmatch.RegexpPattern:
+			v = RouteVar{Name: expr.Name, Constraint: expr.Pattern}
+			def = expr.Default
+		case *pathmatch.Wildcard:
+			v = RouteVar{Name: expr.Name, Wildcard: true}
+			def = expr.Default
+		default:
+			continue
+		}
+		v.Optional = optional
+		v.Default = def
+		vars = append(vars, v)
+	}
+	return vars
+}
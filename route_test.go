@@ -0,0 +1,197 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var r mux.Router
+	require.Empty(t, r.Routes(), `r.Routes should be empty for the zero value`)
+
+	require.NoError(t, r.Get(`/users/{id}`, noop, mux.WithName(`user`)), `r.Get should succeed`)
+	require.NoError(t, r.Any(`/health`, noop), `r.Any should succeed`)
+	require.NoError(t, r.Group(`/tenants/{tenant}`).Post(`/posts/{post:^[0-9]+$}`, noop), `group.Post should succeed`)
+	require.NoError(t, r.Mount(`/static`, noop), `r.Mount should succeed`)
+	require.NoError(t, r.Get(`/posts[/{page:int}]/{format=json}`, noop), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/files/{path...}`, noop), `r.Get should succeed`)
+
+	expected := []mux.Route{
+		{
+			Method:  http.MethodGet,
+			Pattern: `/users/{id}`,
+			Name:    `user`,
+			Vars:    []mux.RouteVar{{Name: `id`}},
+		},
+		{
+			Pattern: `/health`,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: `/tenants/{tenant}/posts/{post:^[0-9]+$}`,
+			Vars: []mux.RouteVar{
+				{Name: `tenant`},
+				{Name: `post`, Constraint: `^[0-9]+$`},
+			},
+		},
+		{
+			Pattern: `/static`,
+			Mount:   true,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: `/posts[/{page:int}]/{format=json}`,
+			Vars: []mux.RouteVar{
+				{Name: `page`, Type: `int`, Optional: true},
+				{Name: `format`, Optional: true, Default: `json`},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: `/files/{path...}`,
+			Vars:    []mux.RouteVar{{Name: `path`, Wildcard: true}},
+		},
+	}
+	require.Equal(t, expected, r.Routes(), `r.Routes should return the registered routes`)
+}
+
+func TestCurrentRoute(t *testing.T) {
+	describe := func(w http.ResponseWriter, r *http.Request) {
+		route, ok := mux.CurrentRoute(r)
+		if !ok {
+			fmt.Fprint(w, `none`)
+			return
+		}
+		fmt.Fprintf(w, `%s %s name=%s`, route.Method, route.Pattern, route.Name)
+	}
+
+	var users mux.Router
+	require.NoError(t, users.Delete(`/{id}`, http.HandlerFunc(describe), mux.WithName(`delete_user`)), `users.Delete should succeed`)
+
+	var r mux.Router
+	r.Use(func(next http.Handler) http.Handler {
+		// middlewares of the Router see the route as well
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			route, _ := mux.CurrentRoute(req)
+			w.Header().Set(`X-Route`, route.Pattern)
+			next.ServeHTTP(w, req)
+		})
+	})
+	require.NoError(t, r.Get(`/posts/{id:int}`, http.HandlerFunc(describe), mux.WithName(`post`)), `r.Get should succeed`)
+	require.NoError(t, r.Group(`/tenants/{tenant}`).Any(`/settings`, http.HandlerFunc(describe)), `group.Any should succeed`)
+	require.NoError(t, r.Mount(`/users`, &users), `r.Mount should succeed`)
+
+	testcases := []struct {
+		Method   string
+		Path     string
+		Expected string
+		Header   string
+	}{
+		{Method: http.MethodGet, Path: `/posts/123`, Expected: `GET /posts/{id:int} name=post`, Header: `/posts/{id:int}`},
+		{Method: http.MethodPut, Path: `/tenants/acme/settings`, Expected: ` /tenants/{tenant}/settings name=`, Header: `/tenants/{tenant}/settings`},
+		{Method: http.MethodDelete, Path: `/users/123`, Expected: `DELETE /{id} name=delete_user`, Header: `/users`},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Method+" "+tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+			require.Equal(t, http.StatusOK, w.Code, `status code should match`)
+			require.Equal(t, tc.Expected, w.Body.String(), `body should match`)
+			require.Equal(t, tc.Header, w.Header().Get(`X-Route`), `middleware should see the route`)
+		})
+	}
+
+	t.Run("outside of a router", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		describe(w, httptest.NewRequest(http.MethodGet, `/posts/123`, nil))
+		require.Equal(t, `none`, w.Body.String(), `no route should be available`)
+	})
+}
+
+type scopes []string
+
+type rateLimit string
+
+type deprecation struct {
+	Notice string
+}
+
+func TestRouteMetadata(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var r mux.Router
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			route, _ := mux.CurrentRoute(req)
+			if required, ok := mux.RouteMetadata[scopes](route); ok {
+				granted := req.Header.Get(`X-Scope`)
+				for _, scope := range required {
+					if scope != granted {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+				}
+			}
+			if d, ok := mux.RouteMetadata[*deprecation](route); ok {
+				w.Header().Set(`Deprecation`, d.Notice)
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+	require.NoError(t, r.Get(`/users/{id}`, ok, mux.WithMetadata(scopes{`users:read`}, rateLimit(`default`))), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/v1/users/{id}`, ok, mux.WithMetadata(&deprecation{Notice: `use /users/{id}`}), mux.WithMetadata(rateLimit(`low`), rateLimit(`strict`))), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/health`, ok), `r.Get should succeed`)
+	require.Error(t, r.Get(`/invalid`, ok, mux.WithMetadata(nil)), `nil metadata should be rejected`)
+
+	testcases := []struct {
+		Path        string
+		Scope       string
+		Status      int
+		Deprecation string
+	}{
+		{Path: `/users/123`, Scope: `users:read`, Status: http.StatusOK},
+		{Path: `/users/123`, Status: http.StatusForbidden},
+		{Path: `/v1/users/123`, Status: http.StatusOK, Deprecation: `use /users/{id}`},
+		{Path: `/health`, Status: http.StatusOK},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Path+" "+tc.Scope, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.Path, nil)
+			req.Header.Set(`X-Scope`, tc.Scope)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, tc.Status, w.Code, `status code should match`)
+			require.Equal(t, tc.Deprecation, w.Header().Get(`Deprecation`), `deprecation header should match`)
+		})
+	}
+
+	t.Run("introspection", func(t *testing.T) {
+		routes := r.Routes()
+		require.Len(t, routes, 3, `r.Routes should return all routes`)
+		require.Equal(t, []interface{}{scopes{`users:read`}, rateLimit(`default`)}, routes[0].Metadata, `metadata should match`)
+		require.Empty(t, routes[2].Metadata, `routes without metadata should have none`)
+
+		// values of the same type replace earlier ones
+		limit, ok := mux.RouteMetadata[rateLimit](routes[1])
+		require.True(t, ok, `rate limit should be found`)
+		require.Equal(t, rateLimit(`strict`), limit, `the last value should win`)
+
+		_, ok = mux.RouteMetadata[scopes](routes[1])
+		require.False(t, ok, `scopes should not be found`)
+
+		stringer, ok := mux.RouteMetadata[fmt.Stringer](routes[0])
+		require.False(t, ok, `no value implements fmt.Stringer`)
+		require.Nil(t, stringer, `zero value should be returned`)
+	})
+}
@@ -0,0 +1,269 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/lestrrat-go/mux/internal/pathmatch"
+)
+
+// table is an immutable snapshot of the routes registered in a Router.
+// Every change to the routes creates a new table, which replaces the
+// previous one atomically, so that requests can be dispatched without
+// taking any locks.
+type table struct {
+	// paths contains the routes in the order that they were registered
+	paths []*path
+
+	// ranked contains the same routes in order of precedence
+	ranked []*path
+
+	// handlers contains the handlers of the routes in ranked, wrapped
+	// with all middlewares that apply to them
+	handlers []http.Handler
+
+	// keys contains the indices that the routes in ranked are associated
+	// with in the tree, in ascending order. The keys are spaced apart, so
+	// that a new route can usually be ranked in between existing routes
+	// without renumbering them
+	keys []int
+
+	tree  *pathmatch.Tree
+	names map[string]*path
+}
+
+// keySpacing is the distance between the keys of adjacent routes when
+// the table is built from scratch
+const keySpacing = 1 << 10
+
+var emptyTable = &table{}
+
+// load returns the current table. It never returns nil
+func (r *Router) load() *table {
+	if t, ok := r.table.Load().(*table); ok {
+		return t
+	}
+	return emptyTable
+}
+
+// lookup returns the position in ranked of the route associated with
+// the key in the tree
+func (t *table) lookup(key int) int {
+	return sort.SearchInts(t.keys, key)
+}
+
+// publish builds a new table from the routes, and makes it visible to
+// requests. The caller must hold the lock
+func (r *Router) publish(paths []*path) {
+	t := &table{
+		paths:    paths,
+		ranked:   make([]*path, len(paths)),
+		handlers: make([]http.Handler, len(paths)),
+		keys:     make([]int, len(paths)),
+		tree:     pathmatch.NewTree(),
+		names:    make(map[string]*path),
+	}
+
+	// routes that compare equal keep the order of registration
+	copy(t.ranked, paths)
+	sort.SliceStable(t.ranked, func(i, j int) bool {
+		return r.precedes(t.ranked[i], t.ranked[j])
+	})
+
+	for i, p := range t.ranked {
+		t.keys[i] = (i + 1) * keySpacing
+		t.insert(p, t.keys[i])
+		t.handlers[i] = r.chain(p)
+	}
+	for _, p := range paths {
+		if p.name != "" {
+			t.names[p.name] = p
+		}
+	}
+	r.table.Store(t)
+}
+
+// publishAdded makes a newly registered route visible to requests. Unlike
+// publish, the existing table is reused as much as possible, so that
+// registering a route does not take time proportional to the number of
+// routes. The caller must hold the lock
+func (r *Router) publishAdded(p *path) {
+	t := r.load()
+	paths := append(t.paths[:len(t.paths):len(t.paths)], p)
+
+	// routes that compare equal keep the order of registration, so the
+	// new route goes after all routes that do not rank lower
+	i := sort.Search(len(t.ranked), func(i int) bool {
+		return r.precedes(p, t.ranked[i])
+	})
+
+	lo := 0
+	if i > 0 {
+		lo = t.keys[i-1]
+	}
+	hi := lo + 2*keySpacing
+	if i < len(t.keys) {
+		hi = t.keys[i]
+	}
+	if hi-lo < 2 {
+		// there is no room left in between, so the keys are renumbered
+		r.publish(paths)
+		return
+	}
+	key := lo + (hi-lo)/2
+
+	next := &table{
+		paths:    paths,
+		ranked:   insertAt(t.ranked, i, p),
+		handlers: insertAt(t.handlers, i, r.chain(p)),
+		keys:     insertAt(t.keys, i, key),
+		tree:     pathmatch.NewTree(),
+		names:    t.names,
+	}
+	if t.tree != nil {
+		next.tree = t.tree.Clone()
+	}
+	next.insert(p, key)
+	if p.name != "" {
+		next.names = make(map[string]*path, len(t.names)+1)
+		for name, p := range t.names {
+			next.names[name] = p
+		}
+		next.names[p.name] = p
+	}
+	r.table.Store(next)
+}
+
+func (t *table) insert(p *path, key int) {
+	if p.mount {
+		t.tree.InsertPrefix(p.matcher, key)
+	} else {
+		t.tree.Insert(p.matcher, key)
+	}
+}
+
+// insertAt returns a copy of the slice with v inserted at position i
+func insertAt[T any](s []T, i int, v T) []T {
+	result := make([]T, 0, len(s)+1)
+	result = append(result, s[:i]...)
+	result = append(result, v)
+	return append(result, s[i:]...)
+}
+
+// republish rebuilds the current table, which is needed when the
+// middlewares change. The caller must hold the lock
+func (r *Router) republish() {
+	if t := r.load(); len(t.paths) > 0 {
+		r.publish(t.paths)
+	}
+}
+
+// Remove unregisters all routes registered with the given method and
+// pattern. The method and pattern must be the same as those used to
+// register the route, including the prefix of the group that the route
+// was registered through. Use an empty method for routes registered
+// using `Router.Any` or `Router.Mount`.
+//
+// An error is returned if no such route exists. Requests that are
+// being dispatched while the route is removed may still be handled
+// by the route.
+func (r *Router) Remove(method, pattern string) error {
+	n := r.remove(func(p *path) bool {
+		return p.method == method && p.pattern == pattern
+	})
+	if n == 0 {
+		return fmt.Errorf(`route %s not found`, describeRoute(Route{Method: method, Pattern: pattern}))
+	}
+	return nil
+}
+
+// RemoveName unregisters the route that was registered with the given
+// name using `mux.WithName`. An error is returned if no such route exists.
+func (r *Router) RemoveName(name string) error {
+	n := r.remove(func(p *path) bool {
+		return p.name == name
+	})
+	if n == 0 {
+		return fmt.Errorf(`route named %q not found`, name)
+	}
+	return nil
+}
+
+func (r *Router) remove(match func(*path) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.load()
+	paths := make([]*path, 0, len(t.paths))
+	for _, p := range t.paths {
+		if !match(p) {
+			paths = append(paths, p)
+		}
+	}
+
+	removed := len(t.paths) - len(paths)
+	if removed > 0 {
+		r.publish(paths)
+	}
+	return removed
+}
+
+// Swap atomically replaces all routes in the Router with the routes
+// registered in `other`. This allows a complete set of routes to be
+// built and validated in a separate Router, and put into service at
+// once. Requests are never blocked while the routes are being replaced:
+// each request is dispatched using either the old or the new set of
+// routes.
+//
+// The settings of the Router, such as `Router.NotFound`, `Router.Precedence`
+// and the middlewares added using `Router.Use`, are not replaced, and
+// apply to the new routes. In particular, the patterns of the routes are
+// compiled again according to `Router.CaseInsensitive` and
+// `Router.NormalizeUnicode`. The middlewares of the Groups that the routes
+// were registered through still apply, as they were when Swap was called:
+// middlewares added to those Groups afterwards only apply to the routes
+// of `other`. Likewise, routes registered in `other` after Swap returns
+// are not visible in the Router.
+//
+// An error is returned if a pattern cannot be compiled again, in which
+// case the routes of the Router are left untouched.
+func (r *Router) Swap(other *Router) error {
+	paths, err := r.copyPaths(other)
+	if err != nil {
+		return fmt.Errorf(`failed to swap routes: %w`, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publish(paths)
+	return nil
+}
+
+// copyPaths returns copies of the routes of `other`, compiled for the
+// Router. The Groups belong to `other`, so their middlewares are copied
+// into the routes, in the order in which `chain` would apply them
+func (r *Router) copyPaths(other *Router) ([]*path, error) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	src := other.load().paths
+	paths := make([]*path, 0, len(src))
+	for _, p := range src {
+		m, err := r.parse(p.pattern)
+		if err != nil {
+			return nil, err
+		}
+		c := *p
+		c.matcher = m
+		c.group = nil
+		c.middlewares = nil
+		for g := p.group; g != nil; g = g.parent {
+			c.middlewares = append(append([]Middleware(nil), g.middlewares...), c.middlewares...)
+		}
+		c.middlewares = append(c.middlewares, p.middlewares...)
+		r.prepare(&c)
+		paths = append(paths, &c)
+	}
+	return paths, nil
+}
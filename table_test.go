@@ -0,0 +1,188 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemove(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, name)
+		})
+	}
+	serve := func(r *mux.Router, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/users/{id}`, handler(`get`), mux.WithName(`user`)), `r.Get should succeed`)
+	require.NoError(t, r.Delete(`/users/{id}`, handler(`delete`)), `r.Delete should succeed`)
+	require.NoError(t, r.Group(`/admin`).Get(`/users`, handler(`admin`)), `group.Get should succeed`)
+	require.NoError(t, r.Mount(`/static`, handler(`static`)), `r.Mount should succeed`)
+
+	require.NoError(t, r.Remove(http.MethodDelete, `/users/{id}`), `r.Remove should succeed`)
+	require.Error(t, r.Remove(http.MethodDelete, `/users/{id}`), `removing a route twice should fail`)
+	require.Equal(t, http.StatusMethodNotAllowed, serve(&r, http.MethodDelete, `/users/123`).Code, `removed route should not be matched`)
+	require.Equal(t, `get`, serve(&r, http.MethodGet, `/users/123`).Body.String(), `other routes should still be matched`)
+
+	require.NoError(t, r.Remove(http.MethodGet, `/admin/users`), `routes registered through groups should be removable`)
+	require.NoError(t, r.Remove("", `/static`), `mounts should be removable`)
+	require.Equal(t, http.StatusNotFound, serve(&r, http.MethodGet, `/static/app.js`).Code, `removed mount should not be matched`)
+
+	require.NoError(t, r.RemoveName(`user`), `r.RemoveName should succeed`)
+	require.Error(t, r.RemoveName(`user`), `removing a name twice should fail`)
+	_, err := r.URL(`user`)
+	require.Error(t, err, `removed route should not be available to r.URL`)
+	require.NoError(t, r.Get(`/people/{id}`, handler(`get`), mux.WithName(`user`)), `names of removed routes should be reusable`)
+
+	require.Len(t, r.Routes(), 1, `only one route should remain`)
+}
+
+func TestSwap(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, name)
+		})
+	}
+
+	var live mux.Router
+	live.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set(`X-Live`, `1`)
+			next.ServeHTTP(w, req)
+		})
+	})
+	require.NoError(t, live.Get(`/version`, handler(`v1`)), `live.Get should succeed`)
+
+	var staging mux.Router
+	require.NoError(t, staging.Get(`/version`, handler(`v2`)), `staging.Get should succeed`)
+	require.NoError(t, staging.Get(`/new`, handler(`new`)), `staging.Get should succeed`)
+	require.Empty(t, staging.Validate(), `staging routes should not conflict`)
+
+	// requests keep being served while the routes are swapped
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				w := httptest.NewRecorder()
+				live.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/version`, nil))
+				if body := w.Body.String(); body != `v1` && body != `v2` {
+					t.Errorf(`unexpected response %q`, body)
+				}
+			}
+		}()
+	}
+	require.NoError(t, live.Swap(&staging), `live.Swap should succeed`)
+	close(stop)
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	live.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/version`, nil))
+	require.Equal(t, `v2`, w.Body.String(), `new routes should be served`)
+	require.Equal(t, `1`, w.Header().Get(`X-Live`), `middlewares of the live router should apply`)
+	require.Len(t, live.Routes(), 2, `live.Routes should return the new routes`)
+
+	// routes registered in the staging router afterwards are not visible
+	require.NoError(t, staging.Get(`/later`, handler(`later`)), `staging.Get should succeed`)
+	w = httptest.NewRecorder()
+	live.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/later`, nil))
+	require.Equal(t, http.StatusNotFound, w.Code, `routes registered after Swap should not be visible`)
+
+	t.Run("groups", func(t *testing.T) {
+		// the middlewares of the groups are those at the time of Swap
+		header := func(name string) mux.Middleware {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Header().Add(`X-Middleware`, name)
+					next.ServeHTTP(w, req)
+				})
+			}
+		}
+
+		var staging mux.Router
+		g := staging.Group(`/api`)
+		g.Use(header(`group`))
+		nested := g.Group(`/v1`)
+		nested.Use(header(`nested`))
+		require.NoError(t, nested.Get(`/users`, handler(`users`), mux.WithMiddleware(header(`route`))), `nested.Get should succeed`)
+
+		var live mux.Router
+		live.Use(header(`live`))
+		require.NoError(t, live.Swap(&staging), `live.Swap should succeed`)
+
+		serve := func(r *mux.Router) []string {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/api/v1/users`, nil))
+			require.Equal(t, `users`, w.Body.String(), `route should be served`)
+			return w.Header().Values(`X-Middleware`)
+		}
+		require.Equal(t, []string{`live`, `group`, `nested`, `route`}, serve(&live), `middlewares should apply in order`)
+
+		// the group belongs to the staging router, and concurrent calls
+		// to Use must not race with the live router
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Use(header(`late`))
+		}()
+		live.Use(header(`later`))
+		wg.Wait()
+
+		require.Equal(t, []string{`live`, `later`, `group`, `nested`, `route`}, serve(&live), `group middlewares added after Swap should not apply`)
+		require.Equal(t, []string{`group`, `late`, `nested`, `route`}, serve(&staging), `group middlewares should apply to the staging router`)
+	})
+	t.Run("settings", func(t *testing.T) {
+		// the patterns are compiled according to the settings of the
+		// router that the routes are swapped into
+		live := mux.Router{CaseInsensitive: true, NormalizeUnicode: true}
+		require.NoError(t, live.Get(`/users/{id}`, handler(`v1`)), `live.Get should succeed`)
+
+		var staging mux.Router
+		require.NoError(t, staging.Get(`/users/{id}`, handler(`v2`)), `staging.Get should succeed`)
+		require.NoError(t, staging.Get("/cafe\u0301", handler(`cafe`)), `staging.Get should succeed`)
+
+		strict := mux.Router{CaseInsensitive: true}
+		require.NoError(t, strict.Get(`/posts/{id}`, handler(`posts`)), `strict.Get should succeed`)
+		require.NoError(t, strict.Get(`/tags/{id}`, handler(`tags`), mux.WithCaseInsensitive()), `strict.Get should succeed`)
+
+		serve := func(r *mux.Router, path string) int {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, `/`, nil)
+			req.URL.Path = path
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		require.Equal(t, http.StatusOK, serve(&live, `/USERS/1`), `live router should be case-insensitive`)
+		require.NoError(t, live.Swap(&staging), `live.Swap should succeed`)
+		require.Equal(t, http.StatusOK, serve(&live, `/USERS/1`), `swapped routes should be case-insensitive`)
+		require.Equal(t, http.StatusOK, serve(&live, "/caf\u00e9"), `swapped routes should be normalized`)
+		for _, route := range live.Routes() {
+			require.True(t, route.CaseInsensitive, `swapped routes should be reported as case-insensitive`)
+		}
+
+		var sensitive mux.Router
+		require.NoError(t, sensitive.Swap(&strict), `sensitive.Swap should succeed`)
+		require.Equal(t, http.StatusNotFound, serve(&sensitive, `/POSTS/1`), `settings of the other router should not apply`)
+		require.Equal(t, http.StatusOK, serve(&sensitive, `/TAGS/1`), `route options should still apply`)
+		require.Equal(t, http.StatusOK, serve(&strict, `/POSTS/1`), `the other router should not be affected`)
+	})
+}
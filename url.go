@@ -0,0 +1,41 @@
+package mux
+
+import "fmt"
+
+// URL generates the path for the route registered with the given name,
+// using `mux.WithName`. The values for the variable components are
+// specified as a list of name and value pairs:
+//
+//	r.URL(`user_posts`, `id`, `123`, `post`, `456`)
+//
+// The values are escaped as necessary. An error is returned if any of
+// the values are missing, if extra values are given, or if a value does
+// not satisfy the constraints of the variable. For example, values for
+// variables of the form `{name}` may not contain slashes, unless
+// `Router.MatchEscapedPath` is enabled, in which case the slashes are
+// escaped as `%2F`.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf(`mux.Router.URL: params must be given in name and value pairs`)
+	}
+
+	p, ok := r.load().names[name]
+	if !ok {
+		return "", fmt.Errorf(`mux.Router.URL: route named %q not found`, name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	build := p.matcher.Build
+	if r.MatchEscapedPath {
+		build = p.matcher.BuildEscaped
+	}
+	u, err := build(values)
+	if err != nil {
+		return "", fmt.Errorf(`mux.Router.URL: failed to build path for route %q: %w`, name, err)
+	}
+	return u, nil
+}
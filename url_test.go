@@ -0,0 +1,114 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL(t *testing.T) {
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/users/{id}`, noop, mux.WithName(`user`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/users/{id:^[0-9]+}/posts/{post}`, noop, mux.WithName(`user_post`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/files/{path:.*$}`, noop, mux.WithName(`file`)), `r.Get should succeed`)
+	require.NoError(t, r.Group(`/tenants/{tenant}`).Get(`/home`, noop, mux.WithName(`tenant_home`)), `group.Get should succeed`)
+	require.NoError(t, r.Get(`/posts[/{year:int}[/{month:int}]]`, noop, mux.WithName(`posts`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/download/{name}.{ext}`, noop, mux.WithName(`download`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/assets/{path...}`, noop, mux.WithName(`asset`)), `r.Get should succeed`)
+	require.NoError(t, r.Get(`/report/{format=json}`, noop, mux.WithName(`report`)), `r.Get should succeed`)
+	require.Error(t, r.Get(`/other`, noop, mux.WithName(`user`)), `registering a duplicate name should fail`)
+
+	testcases := []struct {
+		Name     string
+		Params   []string
+		Expected string
+		Error    bool
+	}{
+		{Name: `user`, Params: []string{`id`, `123`}, Expected: `/users/123`},
+		{Name: `user`, Params: []string{`id`, `john doe`}, Expected: `/users/john%20doe`},
+		{Name: `user`, Params: []string{`id`, `a/b`}, Error: true},
+		{Name: `user`, Params: []string{`id`, ``}, Error: true},
+		{Name: `user`, Params: []string{}, Error: true},
+		{Name: `user`, Params: []string{`id`}, Error: true},
+		{Name: `user`, Params: []string{`id`, `123`, `extra`, `value`}, Error: true},
+		{Name: `user_post`, Params: []string{`id`, `123`, `post`, `hello`}, Expected: `/users/123/posts/hello`},
+		{Name: `user_post`, Params: []string{`id`, `abc`, `post`, `hello`}, Error: true},
+		{Name: `file`, Params: []string{`path`, `css/main file.css`}, Expected: `/files/css/main%20file.css`},
+		{Name: `tenant_home`, Params: []string{`tenant`, `acme`}, Expected: `/tenants/acme/home`},
+		{Name: `posts`, Expected: `/posts`},
+		{Name: `posts`, Params: []string{`year`, `2020`}, Expected: `/posts/2020`},
+		{Name: `posts`, Params: []string{`year`, `2020`, `month`, `12`}, Expected: `/posts/2020/12`},
+		{Name: `posts`, Params: []string{`month`, `12`}, Error: true},
+		{Name: `posts`, Params: []string{`year`, `abc`}, Error: true},
+		{Name: `report`, Expected: `/report`},
+		{Name: `report`, Params: []string{`format`, `csv`}, Expected: `/report/csv`},
+		{Name: `asset`, Params: []string{`path`, `img/logo v2.png`}, Expected: `/assets/img/logo%20v2.png`},
+		{Name: `asset`, Params: []string{`path`, ``}, Expected: `/assets/`},
+		{Name: `download`, Params: []string{`name`, `report`, `ext`, `tar.gz`}, Expected: `/download/report.tar.gz`},
+		{Name: `download`, Params: []string{`name`, `report.tar`, `ext`, `gz`}, Error: true},
+		{Name: `unknown`, Error: true},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			u, err := r.URL(tc.Name, tc.Params...)
+			if tc.Error {
+				require.Error(t, err, `r.URL should fail`)
+				return
+			}
+			require.NoError(t, err, `r.URL should succeed`)
+			require.Equal(t, tc.Expected, u, `r.URL should return the expected path`)
+
+			// the generated URL must be routed back to the same route
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+			require.Equal(t, http.StatusOK, w.Code, `generated URL should be routable`)
+		})
+	}
+
+	t.Run("MatchEscapedPath", func(t *testing.T) {
+		var captured string
+		r := mux.Router{MatchEscapedPath: true}
+		require.NoError(t, r.Get(`/users/{id}/posts`, http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			captured = mux.Vars(req).Get(`id`)
+		}), mux.WithName(`user_posts`)), `r.Get should succeed`)
+		require.NoError(t, r.Get(`/download/{name}.{ext}`, noop, mux.WithName(`download`)), `r.Get should succeed`)
+
+		testcases := []struct {
+			Name     string
+			Params   []string
+			Expected string
+			Error    bool
+		}{
+			{Name: `user_posts`, Params: []string{`id`, `a/b`}, Expected: `/users/a%2Fb/posts`},
+			{Name: `user_posts`, Params: []string{`id`, `50%/100%`}, Expected: `/users/50%25%2F100%25/posts`},
+			{Name: `user_posts`, Params: []string{`id`, `/`}, Expected: `/users/%2F/posts`},
+			{Name: `user_posts`, Params: []string{`id`, ``}, Error: true},
+			{Name: `download`, Params: []string{`name`, `a/b`, `ext`, `txt`}, Expected: `/download/a%2Fb.txt`},
+		}
+		for _, tc := range testcases {
+			tc := tc
+			t.Run(tc.Params[1], func(t *testing.T) {
+				u, err := r.URL(tc.Name, tc.Params...)
+				if tc.Error {
+					require.Error(t, err, `r.URL should fail`)
+					return
+				}
+				require.NoError(t, err, `r.URL should succeed`)
+				require.Equal(t, tc.Expected, u, `r.URL should return the expected path`)
+
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+				require.Equal(t, http.StatusOK, w.Code, `generated URL should be routable`)
+				if tc.Name == `user_posts` {
+					require.Equal(t, tc.Params[1], captured, `the value should be captured as given`)
+				}
+			})
+		}
+	})
+}
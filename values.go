@@ -0,0 +1,200 @@
+package mux
+
+import (
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrVarNotFound is wrapped in a *VarError when the requested
+// variable does not exist.
+var ErrVarNotFound = errors.New(`variable not found`)
+
+// VarError is the error returned when a variable does not exist, or
+// when its value cannot be converted to the requested type.
+type VarError struct {
+	// Name is the name of the variable
+	Name string
+
+	// Value is the raw value of the variable
+	Value string
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *VarError) Error() string {
+	if errors.Is(e.Err, ErrVarNotFound) {
+		return fmt.Sprintf(`variable %q: %s`, e.Name, e.Err)
+	}
+	return fmt.Sprintf(`variable %q: invalid value %q: %s`, e.Name, e.Value, e.Err)
+}
+
+func (e *VarError) Unwrap() error {
+	return e.Err
+}
+
+type values map[string]string
+
+func (v values) Get(name string) string {
+	return v[name]
+}
+
+func (v values) Has(name string) bool {
+	_, ok := v[name]
+	return ok
+}
+
+func (v values) Int(name string) (int, error) {
+	return lookupVar[int](v, name)
+}
+
+func (v values) Int64(name string) (int64, error) {
+	return lookupVar[int64](v, name)
+}
+
+func (v values) Uint(name string) (uint, error) {
+	return lookupVar[uint](v, name)
+}
+
+func (v values) Bool(name string) (bool, error) {
+	return lookupVar[bool](v, name)
+}
+
+func (v values) UUID(name string) ([16]byte, error) {
+	return lookupVar[[16]byte](v, name)
+}
+
+func (v values) Time(name, layout string) (time.Time, error) {
+	s, ok := v[name]
+	if !ok {
+		return time.Time{}, &VarError{Name: name, Err: ErrVarNotFound}
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, &VarError{Name: name, Value: s, Err: err}
+	}
+	return t, nil
+}
+
+// Var returns the value of the variable converted to the type T.
+//
+// Conversions are available for strings, booleans, integers, floating
+// point numbers, time.Duration, `[16]byte` (UUIDs), and any type whose
+// pointer implements encoding.TextUnmarshaler. Other types can be
+// supported by registering a decoder using `mux.RegisterDecoder`.
+func Var[T any](req *http.Request, name string) (T, error) {
+	return lookupVar[T](Vars(req), name)
+}
+
+func lookupVar[T any](v Values, name string) (T, error) {
+	var zero T
+	if !v.Has(name) {
+		return zero, &VarError{Name: name, Err: ErrVarNotFound}
+	}
+
+	s := v.Get(name)
+	decoded, err := decode(reflect.TypeOf(&zero).Elem(), s)
+	if err != nil {
+		return zero, &VarError{Name: name, Value: s, Err: err}
+	}
+	return decoded.Interface().(T), nil
+}
+
+var decodersMu sync.RWMutex
+var decoders = map[reflect.Type]func(string) (interface{}, error){
+	reflect.TypeOf(time.Duration(0)): func(s string) (interface{}, error) {
+		return time.ParseDuration(s)
+	},
+	reflect.TypeOf([16]byte{}): func(s string) (interface{}, error) {
+		return parseUUID(s)
+	},
+}
+
+// RegisterDecoder registers a function that converts the value of a
+// variable to the type T. It is used by `mux.Var` and `mux.Bind`, and
+// replaces any existing decoder for the same type.
+func RegisterDecoder[T any](fn func(string) (T, error)) {
+	var zero T
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[reflect.TypeOf(&zero).Elem()] = func(s string) (interface{}, error) {
+		return fn(s)
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decode converts the string to a value of the given type
+func decode(typ reflect.Type, s string) (reflect.Value, error) {
+	decodersMu.RLock()
+	fn, ok := decoders[typ]
+	decodersMu.RUnlock()
+	if ok {
+		v, err := fn(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(v), nil
+	}
+
+	if reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		ptr := reflect.New(typ)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr.Elem(), nil
+	}
+
+	rv := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.SetFloat(f)
+	default:
+		return reflect.Value{}, fmt.Errorf(`no decoder available for type %s`, typ)
+	}
+	return rv, nil
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf(`invalid UUID format`)
+	}
+
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, fmt.Errorf(`invalid UUID format: %w`, err)
+	}
+	return u, nil
+}
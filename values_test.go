@@ -0,0 +1,120 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type upper string
+
+func (u *upper) UnmarshalText(b []byte) error {
+	*u = upper(strings.ToUpper(string(b)))
+	return nil
+}
+
+type point struct {
+	X, Y string
+}
+
+func TestValues(t *testing.T) {
+	mux.RegisterDecoder(func(s string) (point, error) {
+		i := strings.IndexByte(s, ',')
+		if i < 0 {
+			return point{}, errors.New(`expected x,y`)
+		}
+		return point{X: s[:i], Y: s[i+1:]}, nil
+	})
+
+	var vars mux.Values
+	var req *http.Request
+
+	var r mux.Router
+	require.NoError(t, r.Get(`/{int}/{bool}/{uuid}/{date}/{empty:^}/{text}/{point}`, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		vars = mux.Vars(r)
+		req = r
+	})), `r.Get should succeed`)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, `/-42/true/6ba7b810-9dad-11d1-80b4-00c04fd430c8/2022-02-28//hello/1,2`, nil))
+	require.NotNil(t, vars, `handler should be called`)
+
+	t.Run("Has", func(t *testing.T) {
+		require.True(t, vars.Has(`int`))
+		require.True(t, vars.Has(`empty`), `empty variables should exist`)
+		require.Equal(t, ``, vars.Get(`empty`))
+		require.False(t, vars.Has(`missing`))
+	})
+	t.Run("Int", func(t *testing.T) {
+		v, err := vars.Int(`int`)
+		require.NoError(t, err)
+		require.Equal(t, -42, v)
+
+		v64, err := vars.Int64(`int`)
+		require.NoError(t, err)
+		require.Equal(t, int64(-42), v64)
+
+		_, err = vars.Uint(`int`)
+		require.Error(t, err, `negative values should not be converted to uint`)
+
+		_, err = vars.Int(`text`)
+		var verr *mux.VarError
+		require.True(t, errors.As(err, &verr), `error should be a *mux.VarError`)
+		require.Equal(t, `text`, verr.Name)
+		require.Equal(t, `hello`, verr.Value)
+
+		_, err = vars.Int(`missing`)
+		require.True(t, errors.Is(err, mux.ErrVarNotFound), `error should wrap mux.ErrVarNotFound`)
+	})
+	t.Run("Bool", func(t *testing.T) {
+		v, err := vars.Bool(`bool`)
+		require.NoError(t, err)
+		require.True(t, v)
+	})
+	t.Run("UUID", func(t *testing.T) {
+		v, err := vars.UUID(`uuid`)
+		require.NoError(t, err)
+		require.Equal(t, [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}, v)
+
+		_, err = vars.UUID(`text`)
+		require.Error(t, err)
+	})
+	t.Run("Time", func(t *testing.T) {
+		v, err := vars.Time(`date`, `2006-01-02`)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2022, time.February, 28, 0, 0, 0, 0, time.UTC), v)
+	})
+	t.Run("Var", func(t *testing.T) {
+		i, err := mux.Var[int8](req, `int`)
+		require.NoError(t, err)
+		require.Equal(t, int8(-42), i)
+
+		f, err := mux.Var[float64](req, `int`)
+		require.NoError(t, err)
+		require.Equal(t, float64(-42), f)
+
+		u, err := mux.Var[upper](req, `text`)
+		require.NoError(t, err)
+		require.Equal(t, upper(`HELLO`), u)
+
+		p, err := mux.Var[point](req, `point`)
+		require.NoError(t, err)
+		require.Equal(t, point{X: `1`, Y: `2`}, p)
+
+		_, err = mux.Var[point](req, `text`)
+		require.Error(t, err)
+
+		_, err = mux.Var[[]string](req, `text`)
+		require.Error(t, err, `types without a decoder should fail`)
+	})
+	t.Run("outside of dispatch", func(t *testing.T) {
+		vars := mux.Vars(httptest.NewRequest(http.MethodGet, `/`, nil))
+		require.NotNil(t, vars)
+		require.False(t, vars.Has(`int`))
+	})
+}